@@ -2,67 +2,69 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/admin"
+	"github.com/hoo47/kafka_ex/internal/bootstrap"
 	"github.com/hoo47/kafka_ex/internal/config"
 	"github.com/hoo47/kafka_ex/internal/events"
 	"github.com/hoo47/kafka_ex/internal/events/handlers"
 	"github.com/hoo47/kafka_ex/internal/kafka"
-	"github.com/hoo47/kafka_ex/internal/schema"
 	pkgevents "github.com/hoo47/kafka_ex/pkg/events"
 )
 
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	configPath := flag.String("config", "config/config.yml", "path to the config file")
+	profile := flag.String("profile", os.Getenv("APP_PROFILE"), "config profile overlay to apply, e.g. dev, staging, prod")
+	provisionTopics := flag.Bool("provision-topics", false, "create missing topics declared in config and report drift before consuming")
+	flag.Parse()
+
+	// 로그 레벨은 SIGHUP으로 설정을 다시 읽을 때 바뀝니다.
+	var logLevel slog.LevelVar
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: &logLevel}))
 
 	// 설정 로드
-	cfg, err := config.Load("config/config.yml")
+	cfg, err := config.Load(*configPath, *profile)
 	if err != nil {
 		logger.Error("Failed to load config", "error", err)
 		os.Exit(1)
 	}
-
-	// Schema Registry 설정
-	registry := schema.NewSchemaRegistry(cfg.SchemaRegistry.URL)
-	registry.RegisterPrototype("AppInstallEvent", &pkgevents.AppInstallEvent{})
-	registry.RegisterPrototype("AppUninstallEvent", &pkgevents.AppUninstallEvent{})
-
-	subjects := map[string]string{
-		"AppInstallEvent":   cfg.SchemaRegistry.Subjects.AppInstall,
-		"AppUninstallEvent": cfg.SchemaRegistry.Subjects.AppUninstall,
-	}
-	if err := registry.RegisterSchemas(subjects); err != nil {
-		logger.Error("Failed to register schemas", "error", err)
+	if err := logLevel.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+		logger.Error("Invalid log level", "error", err)
 		os.Exit(1)
 	}
 
-	// Codec 생성
-	codec := schema.NewCodec(registry)
-
-	// Kafka 설정
-	config := sarama.NewConfig()
-	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
-	config.Consumer.Offsets.Initial = sarama.OffsetOldest
-
-	// 이벤트 라우터 설정
-	router := events.NewEventRouter(logger)
+	if *provisionTopics {
+		if err := provision(cfg, logger); err != nil {
+			logger.Error("Failed to provision topics", "error", err)
+			os.Exit(1)
+		}
+	}
 
-	// 핸들러 등록
-	router.RegisterHandler(
-		handlers.NewAppInstallHandler(logger),
-		&pkgevents.AppInstallEvent{},
-	)
-	router.RegisterHandler(
-		handlers.NewAppUninstallHandler(logger),
-		&pkgevents.AppUninstallEvent{},
+	// 이벤트 카탈로그로 Schema Registry, Codec, 라우터 구성
+	components, err := bootstrap.Build(cfg, logger,
+		events.Typed[*pkgevents.AppInstallEvent](handlers.NewAppInstallHandler(logger)),
+		events.Typed[*pkgevents.AppUninstallEvent](handlers.NewAppUninstallHandler(logger)),
 	)
+	if err != nil {
+		logger.Error("Failed to bootstrap events", "error", err)
+		os.Exit(1)
+	}
 
-	// Consumer 생성
-	consumer := kafka.NewConsumer(router, logger, codec)
+	// Kafka 설정
+	config, err := kafka.NewConsumerConfig(cfg)
+	if err != nil {
+		logger.Error("Invalid kafka consumer config", "error", err)
+		os.Exit(1)
+	}
 
 	// Consumer 그룹 생성
 	group, err := sarama.NewConsumerGroup(cfg.Kafka.Brokers, cfg.Kafka.Consumer.GroupID, config)
@@ -71,26 +73,127 @@ func main() {
 		os.Exit(1)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Consumer 생성. 서킷 브레이커가 열리면 컨슈머 그룹으로 파티션을 멈춥니다.
+	consumerOpts := []kafka.ConsumerOption{
+		kafka.WithHandlingPolicy(kafka.NewHandlingPolicy(cfg)),
+		kafka.WithConcurrency(cfg.Kafka.Consumer.Concurrency.Default, cfg.Kafka.Consumer.Concurrency.Topics),
+		kafka.WithPauser(group),
+	}
+	appOpts := []kafka.AppOption{kafka.WithShutdownTimeout(cfg.Kafka.Consumer.ShutdownTimeout)}
+
+	// 처리에 실패한 메시지를 보낼 DLQ producer
+	if cfg.Kafka.Topics.DLQ != "" {
+		producer, err := newDLQProducer(cfg)
+		if err != nil {
+			logger.Error("Error creating DLQ producer", "error", err)
+			os.Exit(1)
+		}
+		consumerOpts = append(consumerOpts, kafka.WithDeadLetterQueue(producer, cfg.Kafka.Topics.DLQ))
+		appOpts = append(appOpts, kafka.WithCloser(producer))
+	}
+	consumer := kafka.NewConsumer(components.Router, logger, components.Codec, consumerOpts...)
 
-	// 시그널 처리
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	if cfg.Admin.Addr != "" {
+		server := &http.Server{
+			Addr:              cfg.Admin.Addr,
+			Handler:           admin.NewHandler(consumer),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Admin server stopped", "error", err)
+			}
+		}()
+		appOpts = append(appOpts, kafka.WithCloser(server))
+		logger.Info("Serving admin endpoints", "addr", cfg.Admin.Addr)
+	}
 
-	topics := []string{cfg.Kafka.Topics.AppEvents}
+	// 시그널 처리
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
+	reloads := make(chan os.Signal, 1)
+	signal.Notify(reloads, syscall.SIGHUP)
 	go func() {
+		current := cfg
 		for {
-			if err := group.Consume(ctx, topics, consumer); err != nil {
-				logger.Error("Error from consumer", "error", err)
-			}
-			if ctx.Err() != nil {
+			select {
+			case <-reloads:
+				if next, ok := reload(current, *configPath, *profile, &logLevel, consumer, logger); ok {
+					current = next
+				}
+			case <-ctx.Done():
 				return
 			}
 		}
 	}()
 
-	<-signals
-	logger.Info("Shutting down")
+	app := kafka.NewApp(group, consumer, components.Catalog.Topics(), logger, appOpts...)
+
+	logger.Info("Starting consumer", "group_id", cfg.Kafka.Consumer.GroupID)
+	if err := app.Run(ctx); err != nil {
+		logger.Error("Consumer stopped", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Shut down")
+}
+
+// reload는 설정 파일을 다시 읽어 로그 레벨과 메시지 처리 설정을 적용합니다.
+// 재시작해야 적용되는 항목이 바뀌었으면 아무것도 적용하지 않고 바뀐 항목을 로그로 남깁니다.
+func reload(cfg *config.Config, path, profile string, logLevel *slog.LevelVar, consumer *kafka.Consumer, logger *slog.Logger) (*config.Config, bool) {
+	next, err := config.Load(path, profile)
+	if err != nil {
+		logger.Error("Config reload failed, keeping current config", "error", err)
+		return nil, false
+	}
+	if fields := config.RestartRequired(cfg, next); len(fields) > 0 {
+		logger.Error("Config reload rejected: changed fields require a restart", "fields", fields)
+		return nil, false
+	}
+
+	// log.level은 Load에서 검증되었습니다.
+	_ = logLevel.UnmarshalText([]byte(next.Log.Level))
+	consumer.SetPolicy(kafka.NewHandlingPolicy(next))
+
+	logger.Info("Config reloaded", "changed", config.Changed(cfg, next))
+	return next, true
+}
+
+func newDLQProducer(cfg *config.Config) (sarama.SyncProducer, error) {
+	producerConfig, err := kafka.NewClientConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	producerConfig.Producer.Return.Successes = true
+	producerConfig.Producer.RequiredAcks = sarama.WaitForAll
+	return sarama.NewSyncProducer(cfg.Kafka.Brokers, producerConfig)
+}
+
+// provision은 선언된 토픽 중 없는 토픽을 생성합니다. 선언과 다른 토픽은 경고만 남기고 계속 진행합니다.
+func provision(cfg *config.Config, logger *slog.Logger) error {
+	adminConfig, err := kafka.NewClientConfig(cfg)
+	if err != nil {
+		return err
+	}
+	admin, err := sarama.NewClusterAdmin(cfg.Kafka.Brokers, adminConfig)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	report, err := kafka.NewTopicProvisioner(admin).Provision(cfg.Kafka.Provisioning.Topics, false)
+	if err != nil {
+		return err
+	}
+	for _, topic := range report.Created {
+		logger.Info("Created topic", "topic", topic)
+	}
+	for _, drift := range report.Drift {
+		logger.Warn("Topic differs from config",
+			"topic", drift.Topic,
+			"setting", drift.Setting,
+			"want", drift.Want,
+			"actual", drift.Actual)
+	}
+	return nil
 }
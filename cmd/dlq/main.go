@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/bootstrap"
+	"github.com/hoo47/kafka_ex/internal/config"
+	"github.com/hoo47/kafka_ex/internal/dlq"
+	"github.com/hoo47/kafka_ex/internal/kafka"
+	"github.com/hoo47/kafka_ex/internal/schema"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	// 카탈로그의 proto 메시지를 전역 proto 레지스트리에 등록합니다.
+	_ "github.com/hoo47/kafka_ex/pkg/events"
+)
+
+const usage = `Usage: dlq [flags] <command>
+
+Commands:
+  list     print DLQ messages with decoded payloads and failure headers as JSON lines
+  redrive  send matching messages back to their original topic (or -topic)
+  purge    delete messages that failed before -until, or every message with -all
+
+Flags:
+`
+
+type options struct {
+	filter   dlq.Filter
+	all      bool
+	topic    string
+	dryRun   bool
+	operator string
+}
+
+func main() {
+	configPath := flag.String("config", "config/config.yml", "path to the config file")
+	profile := flag.String("profile", os.Getenv("APP_PROFILE"), "config profile overlay to apply, e.g. dev, staging, prod")
+	eventType := flag.String("type", "", "only messages of this event type")
+	errorText := flag.String("error", "", "only messages whose failure contains this text")
+	since := flag.String("since", "", "only messages that failed at or after this RFC 3339 time")
+	until := flag.String("until", "", "only messages that failed before this RFC 3339 time")
+	positions := flag.String("offsets", "", "only these messages, as comma separated partition:offset pairs")
+	all := flag.Bool("all", false, "allow redrive or purge without any filter")
+	topic := flag.String("topic", "", "redrive to this topic instead of the original topic")
+	dryRun := flag.Bool("dry-run", false, "report what redrive or purge would do without changing anything")
+	operator := flag.String("operator", os.Getenv("USER"), "name recorded in the audit log")
+	timeout := flag.Duration("timeout", time.Minute, "maximum time to read the DLQ topic")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// 표준 출력은 list 결과에 사용하므로 로그는 표준 에러로 남깁니다.
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	command := flag.Arg(0)
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// 설정 로드
+	cfg, err := config.Load(*configPath, *profile)
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Kafka.Topics.DLQ == "" {
+		logger.Error("kafka.topics.dlq is not configured")
+		os.Exit(1)
+	}
+
+	filter, err := parseFilter(*eventType, *errorText, *since, *until, *positions)
+	if err != nil {
+		logger.Error("Invalid filter", "error", err)
+		os.Exit(2)
+	}
+	opts := options{
+		filter:   filter,
+		all:      *all,
+		topic:    *topic,
+		dryRun:   *dryRun,
+		operator: *operator,
+	}
+
+	clientConfig, err := kafka.NewClientConfig(cfg)
+	if err != nil {
+		logger.Error("Invalid kafka config", "error", err)
+		os.Exit(1)
+	}
+	clientConfig.Consumer.Return.Errors = true
+	clientConfig.Producer.Return.Successes = true
+	clientConfig.Producer.RequiredAcks = sarama.WaitForAll
+
+	client, err := sarama.NewClient(cfg.Kafka.Brokers, clientConfig)
+	if err != nil {
+		logger.Error("Error creating kafka client", "error", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	reader := dlq.NewReader(client, cfg.Kafka.Topics.DLQ)
+	switch command {
+	case "list":
+		err = list(ctx, cfg, reader, opts, logger)
+	case "redrive":
+		err = redrive(ctx, cfg, client, reader, opts, logger)
+	case "purge":
+		err = purge(ctx, cfg, clientConfig, reader, opts, logger)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		logger.Error("DLQ command failed", "command", command, "error", err)
+		os.Exit(1)
+	}
+}
+
+// listedMessage는 list가 출력하는 한 줄입니다.
+type listedMessage struct {
+	Partition         int32           `json:"partition"`
+	Offset            int64           `json:"offset"`
+	Key               string          `json:"key,omitempty"`
+	Type              string          `json:"type"`
+	Reason            string          `json:"reason"`
+	Error             string          `json:"error"`
+	OriginalTopic     string          `json:"original_topic"`
+	OriginalPartition int32           `json:"original_partition"`
+	OriginalOffset    int64           `json:"original_offset"`
+	FailedAt          time.Time       `json:"failed_at"`
+	Payload           json.RawMessage `json:"payload,omitempty"`
+	DecodeError       string          `json:"decode_error,omitempty"`
+}
+
+func list(ctx context.Context, cfg *config.Config, reader *dlq.Reader, opts options, logger *slog.Logger) error {
+	// 이벤트 카탈로그로 Schema Registry와 Codec 구성
+	components, err := bootstrap.Build(cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to bootstrap events: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	return reader.Scan(ctx, opts.filter, func(r dlq.Record) error {
+		listed := listedMessage{
+			Partition:         r.Partition,
+			Offset:            r.Offset,
+			Key:               string(r.Key),
+			Type:              r.EventType,
+			Reason:            r.Reason,
+			Error:             r.Error,
+			OriginalTopic:     r.OriginalTopic,
+			OriginalPartition: r.OriginalPartition,
+			OriginalOffset:    r.OriginalOffset,
+			FailedAt:          r.FailedAt,
+		}
+		if payload, err := decode(components.Codec, r); err != nil {
+			listed.DecodeError = err.Error()
+		} else {
+			listed.Payload = payload
+		}
+		return enc.Encode(listed)
+	})
+}
+
+// decode는 메시지 값을 역직렬화해 JSON으로 변환합니다. 잘못된 형식이라 DLQ로 온 메시지는 실패합니다.
+func decode(codec *schema.Codec, r dlq.Record) (json.RawMessage, error) {
+	event, err := codec.Deserialize(r.Value, r.EventType)
+	if err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(event)
+}
+
+func redrive(ctx context.Context, cfg *config.Config, client sarama.Client, reader *dlq.Reader, opts options, logger *slog.Logger) error {
+	if !opts.all && isEmpty(opts.filter) {
+		return fmt.Errorf("redrive needs a filter, or -all to redrive every message")
+	}
+
+	var records []dlq.Record
+	err := reader.Scan(ctx, opts.filter, func(r dlq.Record) error {
+		records = append(records, r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		for _, r := range records {
+			target := opts.topic
+			if target == "" {
+				target = r.OriginalTopic
+			}
+			logger.Info("Would redrive message", "partition", r.Partition, "offset", r.Offset, "type", r.EventType, "target", target)
+		}
+		logger.Info("Dry run finished", "messages", len(records))
+		return nil
+	}
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		return fmt.Errorf("failed to create producer: %w", err)
+	}
+	defer producer.Close()
+
+	audit := dlq.NewAuditLog(cfg.DLQ.AuditLog, opts.operator)
+	sent, err := dlq.NewRedriver(producer, audit, cfg.Kafka.Topics.DLQ).Redrive(records, opts.topic)
+	logger.Info("Redrove messages", "sent", sent, "matched", len(records), "audit_log", cfg.DLQ.AuditLog)
+	return err
+}
+
+func purge(ctx context.Context, cfg *config.Config, clientConfig *sarama.Config, reader *dlq.Reader, opts options, logger *slog.Logger) error {
+	// 토픽의 메시지는 파티션 앞에서부터만 지울 수 있으므로 실패 시각 외의 조건은 지원하지 않습니다.
+	f := opts.filter
+	if f.EventType != "" || f.Error != "" || !f.Since.IsZero() || len(f.Positions) > 0 {
+		return fmt.Errorf("purge only supports -until, because records can only be deleted from the start of a partition")
+	}
+	if !opts.all && f.Until.IsZero() {
+		return fmt.Errorf("purge needs -until, or -all to delete every message")
+	}
+
+	offsets, err := reader.PurgeOffsets(ctx, f.Until)
+	if err != nil {
+		return err
+	}
+	for partition, offset := range offsets {
+		logger.Info("Purging messages", "partition", partition, "before_offset", offset, "dry_run", opts.dryRun)
+	}
+	if opts.dryRun || len(offsets) == 0 {
+		return nil
+	}
+
+	admin, err := sarama.NewClusterAdmin(cfg.Kafka.Brokers, clientConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create cluster admin: %w", err)
+	}
+	defer admin.Close()
+
+	if err := admin.DeleteRecords(cfg.Kafka.Topics.DLQ, offsets); err != nil {
+		return fmt.Errorf("failed to delete records: %w", err)
+	}
+
+	entries := make([]dlq.AuditEntry, 0, len(offsets))
+	for partition, offset := range offsets {
+		entries = append(entries, dlq.AuditEntry{
+			Action:    dlq.ActionPurge,
+			DLQTopic:  cfg.Kafka.Topics.DLQ,
+			Partition: partition,
+			Offset:    offset,
+		})
+	}
+	return dlq.NewAuditLog(cfg.DLQ.AuditLog, opts.operator).Record(entries...)
+}
+
+func parseFilter(eventType, errorText, since, until, positions string) (dlq.Filter, error) {
+	filter := dlq.Filter{
+		EventType: eventType,
+		Error:     errorText,
+	}
+	var err error
+	if since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return filter, fmt.Errorf("invalid -since: %w", err)
+		}
+	}
+	if until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return filter, fmt.Errorf("invalid -until: %w", err)
+		}
+	}
+	for _, pair := range strings.Split(positions, ",") {
+		if pair == "" {
+			continue
+		}
+		partition, offset, ok := strings.Cut(pair, ":")
+		if !ok {
+			return filter, fmt.Errorf("invalid -offsets entry %q, want partition:offset", pair)
+		}
+		p, err := strconv.ParseInt(partition, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid partition in -offsets entry %q: %w", pair, err)
+		}
+		o, err := strconv.ParseInt(offset, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid offset in -offsets entry %q: %w", pair, err)
+		}
+		filter.Positions = append(filter.Positions, dlq.Position{Partition: int32(p), Offset: o})
+	}
+	return filter, nil
+}
+
+func isEmpty(f dlq.Filter) bool {
+	return f.EventType == "" && f.Error == "" && f.Since.IsZero() && f.Until.IsZero() && len(f.Positions) == 0
+}
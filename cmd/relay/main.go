@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/IBM/sarama"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+	"github.com/hoo47/kafka_ex/internal/infrastructure/outbox"
+	"github.com/hoo47/kafka_ex/internal/kafka"
+)
+
+func main() {
+	configPath := flag.String("config", "config/config.yml", "path to the config file")
+	profile := flag.String("profile", os.Getenv("APP_PROFILE"), "config profile overlay to apply, e.g. dev, staging, prod")
+	listQuarantined := flag.Bool("list-quarantined", false, "list quarantined outbox events and exit")
+	requeue := flag.String("requeue", "", "requeue quarantined outbox events by comma-separated IDs, or \"all\", and exit")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// 설정 로드
+	cfg, err := config.Load(*configPath, *profile, config.RequireOutbox())
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	// 데이터베이스 연결
+	db, err := sql.Open(cfg.Outbox.Driver, cfg.Outbox.DSN)
+	if err != nil {
+		logger.Error("Failed to open database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	store, err := outbox.NewStore(cfg.Outbox.Driver, db)
+	if err != nil {
+		logger.Error("Failed to create outbox store", "error", err)
+		os.Exit(1)
+	}
+
+	// 격리된 이벤트 관리
+	if *listQuarantined || *requeue != "" {
+		if err := manageQuarantine(context.Background(), store, *listQuarantined, *requeue); err != nil {
+			logger.Error("Failed to manage quarantined events", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Kafka Producer 생성
+	config, err := kafka.NewClientConfig(cfg)
+	if err != nil {
+		logger.Error("Invalid kafka config", "error", err)
+		os.Exit(1)
+	}
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+
+	transactionalID := os.ExpandEnv(cfg.Outbox.Relay.TransactionalID)
+	if transactionalID != "" {
+		config.Producer.Idempotent = true
+		config.Producer.Transaction.ID = transactionalID
+		config.Net.MaxOpenRequests = 1
+	}
+
+	producer, err := sarama.NewSyncProducer(cfg.Kafka.Brokers, config)
+	if err != nil {
+		logger.Error("Error creating producer", "error", err)
+		os.Exit(1)
+	}
+	defer producer.Close()
+
+	retention, err := newRetention(cfg, db, store, logger)
+	if err != nil {
+		logger.Error("Failed to configure outbox retention", "error", err)
+		os.Exit(1)
+	}
+
+	// 시그널 처리
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	retry := outbox.RetryPolicy{
+		MaxAttempts:    cfg.Outbox.Relay.Retry.MaxAttempts,
+		InitialBackoff: cfg.Outbox.Relay.Retry.InitialBackoff,
+		MaxBackoff:     cfg.Outbox.Relay.Retry.MaxBackoff,
+	}
+
+	go retention.Run(ctx)
+
+	// 이벤트 타입별 토픽은 카탈로그에서 가져오며, 카탈로그에 없는 타입은 app_events로 발행합니다.
+	topics := make(map[string]string, len(cfg.Events))
+	for _, event := range cfg.Events {
+		topics[event.Type] = event.Topic
+	}
+
+	if cfg.Outbox.Relay.Mode == "cdc" {
+		relay := outbox.NewCDCRelay(db, producer, outbox.CDCConfig{
+			DSN:            cfg.Outbox.DSN,
+			Slot:           cfg.Outbox.Relay.CDC.Slot,
+			Publication:    cfg.Outbox.Relay.CDC.Publication,
+			Topic:          cfg.Kafka.Topics.AppEvents,
+			Topics:         topics,
+			StatusInterval: cfg.Outbox.Relay.CDC.StatusInterval,
+			Retry:          retry,
+		}, logger)
+
+		// 예약 이벤트는 복제 슬롯에서 건너뛰므로 발행 시각에 맞춰 폴링으로 발행합니다.
+		scheduled := outbox.NewRelay(store, producer, outbox.RelayConfig{
+			Topic:         cfg.Kafka.Topics.AppEvents,
+			Topics:        topics,
+			BatchSize:     cfg.Outbox.Relay.BatchSize,
+			PollInterval:  cfg.Outbox.Relay.PollInterval,
+			ClaimLease:    cfg.Outbox.Relay.ClaimLease,
+			Retry:         retry,
+			ScheduledOnly: true,
+		}, logger)
+		go scheduled.Run(ctx)
+
+		logger.Info("Starting outbox CDC relay", "topic", cfg.Kafka.Topics.AppEvents, "slot", cfg.Outbox.Relay.CDC.Slot)
+		if err := relay.Run(ctx); err != nil {
+			logger.Error("Relay stopped", "error", err)
+		}
+		logger.Info("Shutting down")
+		return
+	}
+
+	var opts []outbox.RelayOption
+	if cfg.Outbox.Relay.Listen && cfg.Outbox.Driver == "postgres" {
+		listener, err := outbox.NewPostgresListener(cfg.Outbox.DSN, logger)
+		if err != nil {
+			logger.Error("Failed to listen for outbox notifications", "error", err)
+			os.Exit(1)
+		}
+		go listener.Run(ctx)
+		opts = append(opts, outbox.WithNotifier(listener))
+	}
+
+	if transactionalID != "" {
+		adminConfig, err := kafka.NewClientConfig(cfg)
+		if err != nil {
+			logger.Error("Invalid kafka config", "error", err)
+			os.Exit(1)
+		}
+		admin, err := sarama.NewClusterAdmin(cfg.Kafka.Brokers, adminConfig)
+		if err != nil {
+			logger.Error("Error creating cluster admin", "error", err)
+			os.Exit(1)
+		}
+		defer admin.Close()
+		checkpoint := outbox.NewTxnCheckpoint(admin, transactionalID, cfg.Kafka.Topics.AppEvents)
+		opts = append(opts, outbox.WithTxnCheckpoint(checkpoint))
+	}
+
+	relay := outbox.NewRelay(store, producer, outbox.RelayConfig{
+		Topic:        cfg.Kafka.Topics.AppEvents,
+		Topics:       topics,
+		BatchSize:    cfg.Outbox.Relay.BatchSize,
+		PollInterval: cfg.Outbox.Relay.PollInterval,
+		ClaimLease:   cfg.Outbox.Relay.ClaimLease,
+		Retry:        retry,
+	}, logger, opts...)
+
+	logger.Info("Starting outbox relay", "topic", cfg.Kafka.Topics.AppEvents, "transactional_id", transactionalID)
+	if err := relay.Run(ctx); err != nil {
+		logger.Error("Relay stopped", "error", err)
+	}
+	logger.Info("Shutting down")
+}
+
+// newRetention은 설정에 따라 보존 작업을 구성합니다.
+// PostgreSQL은 outbox가 월별 파티션으로 되어 있으므로 보존 기간과 관계없이 파티션을 미리 만듭니다.
+func newRetention(cfg *config.Config, db *sql.DB, store *outbox.SQLStore, logger *slog.Logger) (*outbox.Retention, error) {
+	var archiver outbox.Archiver
+	switch cfg.Outbox.Retention.Archive {
+	case "", "none":
+	case "table":
+		archiver = outbox.NewTableArchiver(store)
+	case "jsonl":
+		archiver = outbox.NewJSONLArchiver(cfg.Outbox.Retention.ArchiveDir)
+	default:
+		return nil, fmt.Errorf("unsupported archive mode: %s", cfg.Outbox.Retention.Archive)
+	}
+
+	var partitions *outbox.PartitionManager
+	if cfg.Outbox.Driver == "postgres" {
+		partitions = outbox.NewPartitionManager(db, logger)
+	}
+
+	return outbox.NewRetention(store, partitions, archiver, outbox.RetentionConfig{
+		Period:           cfg.Outbox.Retention.Period,
+		Interval:         cfg.Outbox.Retention.Interval,
+		BatchSize:        cfg.Outbox.Retention.BatchSize,
+		PartitionsAhead:  cfg.Outbox.Retention.PartitionsAhead,
+		DetachPartitions: cfg.Outbox.Retention.DetachPartitions,
+		IgnoreStatus:     cfg.Outbox.Relay.Mode == "cdc",
+	}, logger), nil
+}
+
+func manageQuarantine(ctx context.Context, store outbox.OutboxStore, list bool, requeue string) error {
+	if list {
+		records, err := store.ListQuarantined(ctx, 1000)
+		if err != nil {
+			return err
+		}
+		for _, rec := range records {
+			fmt.Printf("%s\t%s\t%s\tattempts=%d\t%s\n",
+				rec.ID, rec.Type, rec.CreatedAt.Format(time.RFC3339), rec.Attempts, rec.LastError)
+		}
+	}
+
+	if requeue == "" {
+		return nil
+	}
+
+	var ids []string
+	if requeue != "all" {
+		ids = strings.Split(requeue, ",")
+	}
+	n, err := store.Requeue(ctx, ids)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("requeued %d events\n", n)
+	return nil
+}
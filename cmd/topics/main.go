@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/config"
+	"github.com/hoo47/kafka_ex/internal/kafka"
+)
+
+func main() {
+	configPath := flag.String("config", "config/config.yml", "path to the config file")
+	profile := flag.String("profile", os.Getenv("APP_PROFILE"), "config profile overlay to apply, e.g. dev, staging, prod")
+	dryRun := flag.Bool("dry-run", false, "report topics to create and drift without creating anything")
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// 설정 로드
+	cfg, err := config.Load(*configPath, *profile)
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	adminConfig, err := kafka.NewClientConfig(cfg)
+	if err != nil {
+		logger.Error("Invalid kafka config", "error", err)
+		os.Exit(1)
+	}
+
+	admin, err := sarama.NewClusterAdmin(cfg.Kafka.Brokers, adminConfig)
+	if err != nil {
+		logger.Error("Error creating cluster admin", "error", err)
+		os.Exit(1)
+	}
+	defer admin.Close()
+
+	report, err := kafka.NewTopicProvisioner(admin).Provision(cfg.Kafka.Provisioning.Topics, *dryRun)
+	if err != nil {
+		logger.Error("Failed to provision topics", "error", err)
+		os.Exit(1)
+	}
+
+	action := "created"
+	if *dryRun {
+		action = "would create"
+	}
+	for _, topic := range report.Created {
+		fmt.Printf("%s %s\n", action, topic)
+	}
+	for _, drift := range report.Drift {
+		fmt.Printf("drift %s\n", drift)
+	}
+
+	// 선언과 다른 토픽이 있으면 CI 등에서 확인할 수 있도록 실패로 종료합니다.
+	if len(report.Drift) > 0 {
+		os.Exit(2)
+	}
+}
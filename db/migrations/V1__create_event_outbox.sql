@@ -8,6 +8,7 @@ CREATE TABLE event_outbox (
     published BOOLEAN NOT NULL DEFAULT FALSE,
     
     -- 동시성 제어를 위한 인덱스
-    CONSTRAINT uk_event_payload UNIQUE (aggregate_type, aggregate_id, payload),
-    INDEX idx_unpublished (published) WHERE published = FALSE
-); 
\ No newline at end of file
+    CONSTRAINT uk_event_payload UNIQUE (aggregate_type, aggregate_id, payload)
+);
+
+CREATE INDEX idx_unpublished ON event_outbox (published) WHERE published = FALSE;
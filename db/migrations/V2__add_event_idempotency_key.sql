@@ -0,0 +1,11 @@
+-- 페이로드 기반 중복 제거는 같은 이벤트의 정당한 반복(예: 같은 앱 재설치)을 막고,
+-- 바이트가 다른 재시도는 걸러내지 못하므로 명시적인 멱등성 키로 대체합니다.
+ALTER TABLE event_outbox ADD COLUMN idempotency_key VARCHAR(255);
+
+UPDATE event_outbox SET idempotency_key = id::text WHERE idempotency_key IS NULL;
+
+ALTER TABLE event_outbox ALTER COLUMN idempotency_key SET NOT NULL;
+
+ALTER TABLE event_outbox DROP CONSTRAINT uk_event_payload;
+
+CREATE UNIQUE INDEX uk_event_idempotency_key ON event_outbox (idempotency_key);
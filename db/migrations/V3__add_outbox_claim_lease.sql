@@ -0,0 +1,6 @@
+-- 여러 relay가 같은 레코드를 동시에 발행하지 않도록 선점 만료 시각을 기록합니다.
+ALTER TABLE event_outbox ADD COLUMN claimed_until TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX idx_unpublished;
+
+CREATE INDEX idx_unpublished ON event_outbox (created_at) WHERE published = FALSE;
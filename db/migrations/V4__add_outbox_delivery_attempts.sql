@@ -0,0 +1,23 @@
+-- 발행 실패를 행 단위로 추적해 재시도 간격을 늘리고, 반복해서 실패하는 행은 격리합니다.
+-- in_flight 상태에서 next_attempt_at은 선점 만료 시각을 의미하므로 claimed_until을 대체합니다.
+CREATE TYPE outbox_status AS ENUM ('pending', 'in_flight', 'published', 'failed', 'quarantined');
+
+ALTER TABLE event_outbox
+    ADD COLUMN status outbox_status NOT NULL DEFAULT 'pending',
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE event_outbox SET status = 'published' WHERE published = TRUE;
+
+DROP INDEX idx_unpublished;
+
+ALTER TABLE event_outbox
+    DROP COLUMN published,
+    DROP COLUMN claimed_until;
+
+CREATE INDEX idx_outbox_due ON event_outbox (next_attempt_at)
+    WHERE status IN ('pending', 'in_flight', 'failed');
+
+CREATE INDEX idx_outbox_quarantined ON event_outbox (created_at)
+    WHERE status = 'quarantined';
@@ -0,0 +1,76 @@
+-- 발행이 끝난 행이 계속 쌓이지 않도록 event_outbox를 created_at 기준 월별 파티션으로 전환합니다.
+-- 오래된 파티션은 relay의 보존 작업이 보관 후 삭제(또는 분리)하며, 이후 파티션도 미리 생성합니다.
+-- 파티션 테이블의 고유 인덱스는 created_at을 포함해야 하므로 멱등성 키는 별도 테이블에서 관리합니다.
+ALTER TABLE event_outbox RENAME TO event_outbox_legacy;
+ALTER INDEX idx_outbox_due RENAME TO idx_outbox_legacy_due;
+ALTER INDEX idx_outbox_quarantined RENAME TO idx_outbox_legacy_quarantined;
+
+CREATE TABLE event_outbox (
+    id UUID NOT NULL,
+    aggregate_type VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    payload BYTEA NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status outbox_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE INDEX idx_outbox_due ON event_outbox (next_attempt_at)
+    WHERE status IN ('pending', 'in_flight', 'failed');
+
+CREATE INDEX idx_outbox_quarantined ON event_outbox (created_at)
+    WHERE status = 'quarantined';
+
+CREATE TABLE event_outbox_keys (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    event_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_outbox_keys_created_at ON event_outbox_keys (created_at);
+
+-- 기존 행이 속하는 달부터 다음 달까지의 파티션을 만듭니다. 이름 형식은 relay와 같습니다.
+DO $$
+DECLARE
+    month_start TIMESTAMP := date_trunc('month', COALESCE(
+        (SELECT min(created_at) FROM event_outbox_legacy), now()) AT TIME ZONE 'UTC');
+    last_month TIMESTAMP := date_trunc('month', now() AT TIME ZONE 'UTC') + INTERVAL '1 month';
+BEGIN
+    WHILE month_start <= last_month LOOP
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF event_outbox FOR VALUES FROM (%L) TO (%L)',
+            'event_outbox_p' || to_char(month_start, 'YYYYMM'),
+            to_char(month_start, 'YYYY-MM-DD') || ' 00:00:00+00',
+            to_char(month_start + INTERVAL '1 month', 'YYYY-MM-DD') || ' 00:00:00+00');
+        month_start := month_start + INTERVAL '1 month';
+    END LOOP;
+END $$;
+
+INSERT INTO event_outbox
+SELECT id, aggregate_type, aggregate_id, type, payload, idempotency_key, created_at,
+       status, attempts, last_error, next_attempt_at
+FROM event_outbox_legacy;
+
+INSERT INTO event_outbox_keys (idempotency_key, event_id, created_at)
+SELECT idempotency_key, id, created_at FROM event_outbox_legacy;
+
+DROP TABLE event_outbox_legacy;
+
+-- 보존 기간이 지난 행을 옮겨 두는 보관 테이블입니다.
+CREATE TABLE event_archive (
+    id UUID PRIMARY KEY,
+    aggregate_type VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    payload BYTEA NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INTEGER NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,12 @@
+-- CDC relay 모드는 이 publication을 구독하는 논리 복제 슬롯에서 INSERT를 읽습니다.
+-- 파티션별이 아닌 event_outbox 이름으로 전달되도록 publish_via_partition_root를 사용합니다.
+-- CDC 모드를 사용하려면 wal_level = logical이 필요합니다.
+CREATE PUBLICATION event_outbox_pub FOR TABLE event_outbox
+    WITH (publish = 'insert', publish_via_partition_root = true);
+
+-- CDC relay가 마지막으로 발행을 마친 트랜잭션의 WAL 위치입니다.
+CREATE TABLE event_outbox_relay_offsets (
+    slot_name TEXT PRIMARY KEY,
+    lsn PG_LSN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,7 @@
+-- 트랜잭션 relay는 Kafka 트랜잭션을 시작하기 전에 선점한 행에 배치 ID를 기록하고,
+-- 같은 ID를 트랜잭션 안에서 컨슈머 그룹 오프셋 메타데이터로 커밋합니다.
+-- 커밋 직후 relay가 종료되어도 재시작 시 이 ID로 발행 완료를 표시해 중복 발행을 막습니다.
+ALTER TABLE event_outbox ADD COLUMN relay_batch UUID;
+
+CREATE INDEX idx_outbox_relay_batch ON event_outbox (relay_batch)
+    WHERE status = 'in_flight';
@@ -0,0 +1,8 @@
+-- deliver_at이 있는 행은 그 시각 이후에 발행되는 예약 이벤트입니다.
+-- 저장할 때 next_attempt_at을 deliver_at으로 두므로, 발행 시각이 되지 않은 행은 idx_outbox_due의
+-- 범위 조회에 포함되지 않습니다.
+ALTER TABLE event_outbox ADD COLUMN deliver_at TIMESTAMP WITH TIME ZONE;
+
+-- CDC 모드에서 예약 이벤트만 발행하는 relay의 조회용 인덱스입니다.
+CREATE INDEX idx_outbox_scheduled_due ON event_outbox (next_attempt_at)
+    WHERE deliver_at IS NOT NULL AND status IN ('pending', 'in_flight', 'failed');
@@ -0,0 +1,3 @@
+-- message_key가 있는 행은 aggregate ID 대신 이 값을 Kafka 메시지 키로 발행합니다.
+-- 이벤트 카탈로그의 key_field로 정한 키이며, aggregate_id는 항상 aggregate의 ID로 남습니다.
+ALTER TABLE event_outbox ADD COLUMN message_key VARCHAR(255);
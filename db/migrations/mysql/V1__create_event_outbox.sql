@@ -0,0 +1,14 @@
+CREATE TABLE event_outbox (
+    id CHAR(36) PRIMARY KEY,
+    aggregate_type VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    payload MEDIUMBLOB NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    published BOOLEAN NOT NULL DEFAULT FALSE,
+    claimed_until DATETIME(6) NULL,
+
+    CONSTRAINT uk_event_idempotency_key UNIQUE (idempotency_key),
+    INDEX idx_unpublished (published, created_at)
+);
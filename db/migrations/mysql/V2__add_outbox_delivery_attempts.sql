@@ -0,0 +1,13 @@
+ALTER TABLE event_outbox
+    ADD COLUMN status ENUM('pending', 'in_flight', 'published', 'failed', 'quarantined') NOT NULL DEFAULT 'pending',
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT NULL,
+    ADD COLUMN next_attempt_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6);
+
+UPDATE event_outbox SET status = 'published' WHERE published = TRUE;
+
+ALTER TABLE event_outbox
+    DROP INDEX idx_unpublished,
+    DROP COLUMN published,
+    DROP COLUMN claimed_until,
+    ADD INDEX idx_outbox_due (status, next_attempt_at);
@@ -0,0 +1,14 @@
+-- 보존 기간이 지난 행을 옮겨 두는 보관 테이블입니다.
+CREATE TABLE event_archive (
+    id CHAR(36) PRIMARY KEY,
+    aggregate_type VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    payload MEDIUMBLOB NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    attempts INT NOT NULL,
+    archived_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+ALTER TABLE event_outbox ADD INDEX idx_outbox_status_created_at (status, created_at);
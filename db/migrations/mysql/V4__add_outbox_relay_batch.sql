@@ -0,0 +1,3 @@
+ALTER TABLE event_outbox
+    ADD COLUMN relay_batch CHAR(36) NULL,
+    ADD INDEX idx_outbox_relay_batch (relay_batch);
@@ -0,0 +1,2 @@
+-- 예약 이벤트는 next_attempt_at이 deliver_at으로 저장되므로 idx_outbox_due로 발행 시각이 된 행만 조회합니다.
+ALTER TABLE event_outbox ADD COLUMN deliver_at DATETIME(6) NULL;
@@ -0,0 +1,2 @@
+-- message_key가 있는 행은 aggregate ID 대신 이 값을 Kafka 메시지 키로 발행합니다.
+ALTER TABLE event_outbox ADD COLUMN message_key VARCHAR(255) NULL;
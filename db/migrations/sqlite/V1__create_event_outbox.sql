@@ -0,0 +1,15 @@
+CREATE TABLE event_outbox (
+    id TEXT PRIMARY KEY,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    payload BLOB NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    published BOOLEAN NOT NULL DEFAULT FALSE,
+    claimed_until TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uk_event_idempotency_key ON event_outbox (idempotency_key);
+
+CREATE INDEX idx_unpublished ON event_outbox (created_at) WHERE published = FALSE;
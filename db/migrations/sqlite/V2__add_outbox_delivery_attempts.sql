@@ -0,0 +1,15 @@
+ALTER TABLE event_outbox ADD COLUMN status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'in_flight', 'published', 'failed', 'quarantined'));
+ALTER TABLE event_outbox ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE event_outbox ADD COLUMN last_error TEXT;
+ALTER TABLE event_outbox ADD COLUMN next_attempt_at TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+
+UPDATE event_outbox SET status = 'published' WHERE published = TRUE;
+
+DROP INDEX idx_unpublished;
+
+ALTER TABLE event_outbox DROP COLUMN published;
+ALTER TABLE event_outbox DROP COLUMN claimed_until;
+
+CREATE INDEX idx_outbox_due ON event_outbox (next_attempt_at)
+    WHERE status IN ('pending', 'in_flight', 'failed');
@@ -0,0 +1,4 @@
+ALTER TABLE event_outbox ADD COLUMN relay_batch TEXT;
+
+CREATE INDEX idx_outbox_relay_batch ON event_outbox (relay_batch)
+    WHERE status = 'in_flight';
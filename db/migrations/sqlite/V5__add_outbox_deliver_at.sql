@@ -0,0 +1,4 @@
+ALTER TABLE event_outbox ADD COLUMN deliver_at TIMESTAMP;
+
+CREATE INDEX idx_outbox_scheduled_due ON event_outbox (next_attempt_at)
+    WHERE deliver_at IS NOT NULL AND status IN ('pending', 'in_flight', 'failed');
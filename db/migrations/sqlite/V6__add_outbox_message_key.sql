@@ -0,0 +1 @@
+ALTER TABLE event_outbox ADD COLUMN message_key TEXT;
@@ -0,0 +1,30 @@
+package admin
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/hoo47/kafka_ex/internal/kafka"
+)
+
+// BreakerSource는 서킷 브레이커 상태를 제공합니다. kafka.Consumer가 구현합니다.
+type BreakerSource interface {
+	Breakers() []kafka.BreakerStatus
+}
+
+// NewHandler는 운영용 HTTP 핸들러를 반환합니다.
+//
+//	GET /breakers    서킷 브레이커 상태(JSON)
+//	GET /debug/vars  expvar 지표
+func NewHandler(breakers BreakerSource) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /breakers", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(breakers.Breakers()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return mux
+}
@@ -0,0 +1,101 @@
+package bootstrap
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+	"github.com/hoo47/kafka_ex/internal/events"
+	"github.com/hoo47/kafka_ex/internal/schema"
+	"google.golang.org/protobuf/proto"
+)
+
+// Components는 이벤트 카탈로그로 구성한 스키마 레지스트리, 코덱, 라우터입니다.
+type Components struct {
+	Catalog  *schema.Catalog
+	Registry *schema.SchemaRegistry
+	Codec    *schema.Codec
+	Router   *events.EventRouter
+}
+
+// Build는 설정의 events 카탈로그로 스키마 레지스트리에서 스키마 ID를 가져오고 코덱과 라우터를 만듭니다.
+// 카탈로그의 proto 메시지는 전역 proto 레지스트리에서 찾으므로 생성된 패키지가 바이너리에
+// 포함되어 있어야 합니다. handlers는 카탈로그에 있는 이벤트 타입만 처리할 수 있으며,
+// 이벤트 타입에 filter가 있으면 식을 만족하는 이벤트만 받습니다.
+func Build(cfg *config.Config, logger *slog.Logger, handlers ...events.EventHandler) (*Components, error) {
+	catalog, err := schema.NewCatalog(cfg.Events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build event catalog: %w", err)
+	}
+
+	registry, err := schema.NewSchemaRegistryFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure schema registry: %w", err)
+	}
+	for _, eventType := range catalog.Types() {
+		entry, _ := catalog.Entry(eventType)
+		registry.RegisterPrototype(eventType, entry.Prototype)
+	}
+	if err := registry.RegisterSchemas(catalog.Subjects()); err != nil {
+		return nil, fmt.Errorf("failed to register schemas: %w", err)
+	}
+
+	router := events.NewEventRouter(logger)
+	for _, h := range handlers {
+		// Typed로 감쌌지만 타입 인자에서 메시지 타입을 알 수 없는 핸들러입니다.
+		if typed, ok := h.(interface{ Err() error }); ok && typed.Err() != nil {
+			return nil, fmt.Errorf("handler %T: %w", h, typed.Err())
+		}
+		entry, ok := catalog.Entry(h.EventType())
+		if !ok {
+			return nil, fmt.Errorf("handler %T: event type %s is not in the catalog", h, h.EventType())
+		}
+		// Typed로 감싼 핸들러는 카탈로그의 proto 메시지와 같은 타입을 받아야 합니다.
+		if typed, ok := h.(interface{ Prototype() proto.Message }); ok {
+			want := entry.Prototype.ProtoReflect().Descriptor().FullName()
+			if got := typed.Prototype().ProtoReflect().Descriptor().FullName(); got != want {
+				return nil, fmt.Errorf("handler for %s receives %s, but the catalog declares %s", h.EventType(), got, want)
+			}
+		}
+		opts, err := filterOptions(entry)
+		if err != nil {
+			return nil, err
+		}
+		router.RegisterHandler(h, entry.Prototype, opts...)
+	}
+
+	return &Components{
+		Catalog:  catalog,
+		Registry: registry,
+		Codec:    schema.NewCodec(registry),
+		Router:   router,
+	}, nil
+}
+
+// RegisterBatchHandler는 카탈로그에 있는 이벤트 타입의 배치 핸들러를 라우터에 등록합니다.
+func (c *Components) RegisterBatchHandler(h events.BatchEventHandler) error {
+	entry, ok := c.Catalog.Entry(h.EventType())
+	if !ok {
+		return fmt.Errorf("batch handler %T: event type %s is not in the catalog", h, h.EventType())
+	}
+	opts, err := filterOptions(entry)
+	if err != nil {
+		return err
+	}
+	c.Router.RegisterBatchHandler(h, opts...)
+	return nil
+}
+
+// filterOptions는 카탈로그 항목의 filter 식을 핸들러 조건으로 변환합니다.
+func filterOptions(entry schema.CatalogEntry) ([]events.HandlerOption, error) {
+	desc := entry.Prototype.ProtoReflect().Descriptor()
+	opts := make([]events.HandlerOption, 0, len(entry.Filter))
+	for _, expr := range entry.Filter {
+		predicate, err := events.ParsePredicate(desc, expr)
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", entry.Type, err)
+		}
+		opts = append(opts, events.WithPredicate(predicate))
+	}
+	return opts, nil
+}
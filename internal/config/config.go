@@ -1,41 +1,212 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Config는 애플리케이션 설정입니다. log와 handlers 항목은 컨슈머가 SIGHUP을 받으면 재시작 없이
+// 다시 읽으며, 나머지 항목은 재시작해야 적용됩니다.
 type Config struct {
+	Log struct {
+		// Level은 debug, info, warn, error 중 하나입니다.
+		Level string `yaml:"level"`
+	} `yaml:"log"`
+	Handlers struct {
+		// Disabled에 있는 이벤트 타입의 메시지는 처리하지 않고 오프셋만 커밋합니다.
+		Disabled []string `yaml:"disabled"`
+		// UnknownType은 핸들러가 없는 이벤트 타입의 메시지를 처리하는 방식으로, skip, metric, dlq, halt 중
+		// 하나입니다. 기본 핸들러를 지정하면 사용하지 않습니다.
+		UnknownType string `yaml:"unknown_type"`
+		// Timeout은 이벤트 하나를 처리하는 제한 시간이며, Timeouts에 있는 이벤트 타입은 그 값을 사용합니다.
+		// 0이면 제한하지 않습니다.
+		Timeout  time.Duration            `yaml:"timeout"`
+		Timeouts map[string]time.Duration `yaml:"timeouts"`
+		Retry    struct {
+			MaxAttempts    int           `yaml:"max_attempts"`
+			InitialBackoff time.Duration `yaml:"initial_backoff"`
+			MaxBackoff     time.Duration `yaml:"max_backoff"`
+		} `yaml:"retry"`
+		// Batch는 배치 핸들러에 전달할 배치의 최대 크기와, 첫 메시지를 받은 뒤 최대 대기 시간입니다.
+		Batch struct {
+			MaxSize int           `yaml:"max_size"`
+			MaxAge  time.Duration `yaml:"max_age"`
+		} `yaml:"batch"`
+		RateLimit struct {
+			// PerSecond가 0이면 제한하지 않습니다.
+			PerSecond float64 `yaml:"per_second"`
+			Burst     int     `yaml:"burst"`
+		} `yaml:"rate_limit"`
+		// CircuitBreaker는 이벤트 타입별로 연속 실패가 FailureThreshold에 도달하면 해당 파티션을 멈추고,
+		// OpenDuration이 지나면 메시지 하나로 다시 시도해 성공하면 재개합니다. FailureThreshold가 0이면 사용하지 않습니다.
+		CircuitBreaker struct {
+			FailureThreshold int           `yaml:"failure_threshold"`
+			OpenDuration     time.Duration `yaml:"open_duration"`
+			// Dependencies는 이벤트 타입별 의존 대상 이름이며, 같은 이름의 이벤트 타입은 브레이커를 공유합니다.
+			Dependencies map[string]string `yaml:"dependencies"`
+		} `yaml:"circuit_breaker"`
+	} `yaml:"handlers"`
+	Admin struct {
+		// Addr는 브레이커 상태와 지표를 제공하는 HTTP 주소이며, 비어 있으면 열지 않습니다.
+		Addr string `yaml:"addr"`
+	} `yaml:"admin"`
+	DLQ struct {
+		// AuditLog는 cmd/dlq의 redrive와 purge를 기록하는 JSONL 파일 경로입니다.
+		AuditLog string `yaml:"audit_log"`
+	} `yaml:"dlq"`
 	Kafka struct {
-		Brokers  []string `yaml:"brokers"`
+		Brokers []string `yaml:"brokers"`
+		Version string   `yaml:"version"`
+		TLS     TLS      `yaml:"tls"`
+		SASL    struct {
+			// Mechanism은 PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 중 하나이며 비어 있으면 SASL을 사용하지 않습니다.
+			Mechanism string `yaml:"mechanism"`
+			Username  string `yaml:"username"`
+			Password  Secret `yaml:"password"`
+		} `yaml:"sasl"`
 		Consumer struct {
-			GroupID         string `yaml:"group_id"`
-			AutoOffsetReset string `yaml:"auto_offset_reset"`
+			GroupID           string        `yaml:"group_id"`
+			ClientID          string        `yaml:"client_id"`
+			GroupInstanceID   string        `yaml:"group_instance_id"`
+			AutoOffsetReset   string        `yaml:"auto_offset_reset"`
+			RebalanceStrategy string        `yaml:"rebalance_strategy"`
+			SessionTimeout    time.Duration `yaml:"session_timeout"`
+			HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+			IsolationLevel    string        `yaml:"isolation_level"`
+			ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+			Fetch             struct {
+				MinBytes     int32         `yaml:"min_bytes"`
+				DefaultBytes int32         `yaml:"default_bytes"`
+				MaxBytes     int32         `yaml:"max_bytes"`
+				MaxWait      time.Duration `yaml:"max_wait"`
+			} `yaml:"fetch"`
+			Concurrency struct {
+				Default int            `yaml:"default"`
+				Topics  map[string]int `yaml:"topics"`
+			} `yaml:"concurrency"`
 		} `yaml:"consumer"`
 		Topics struct {
 			AppEvents string `yaml:"app_events"`
+			Retry     string `yaml:"retry"`
+			DLQ       string `yaml:"dlq"`
 		} `yaml:"topics"`
+		Provisioning struct {
+			Topics []TopicSpec `yaml:"topics"`
+		} `yaml:"provisioning"`
 	} `yaml:"kafka"`
 	SchemaRegistry struct {
-		URL      string `yaml:"url"`
-		Subjects struct {
-			AppInstall   string `yaml:"app_install"`
-			AppUninstall string `yaml:"app_uninstall"`
-		} `yaml:"subjects"`
+		URL  string `yaml:"url"`
+		TLS  TLS    `yaml:"tls"`
+		Auth struct {
+			// Username이 있으면 basic auth를, 없고 BearerToken이 있으면 bearer 토큰을 사용합니다.
+			Username    string `yaml:"username"`
+			Password    Secret `yaml:"password"`
+			BearerToken Secret `yaml:"bearer_token"`
+		} `yaml:"auth"`
 	} `yaml:"schema_registry"`
+	Events []EventSpec `yaml:"events"`
+	Outbox struct {
+		Driver string `yaml:"driver"`
+		DSN    string `yaml:"dsn"`
+		Relay  struct {
+			Mode            string        `yaml:"mode"`
+			BatchSize       int           `yaml:"batch_size"`
+			PollInterval    time.Duration `yaml:"poll_interval"`
+			ClaimLease      time.Duration `yaml:"claim_lease"`
+			Listen          bool          `yaml:"listen"`
+			TransactionalID string        `yaml:"transactional_id"`
+			Retry           struct {
+				MaxAttempts    int           `yaml:"max_attempts"`
+				InitialBackoff time.Duration `yaml:"initial_backoff"`
+				MaxBackoff     time.Duration `yaml:"max_backoff"`
+			} `yaml:"retry"`
+			CDC struct {
+				Slot           string        `yaml:"slot"`
+				Publication    string        `yaml:"publication"`
+				StatusInterval time.Duration `yaml:"status_interval"`
+			} `yaml:"cdc"`
+		} `yaml:"relay"`
+		Retention struct {
+			Period           time.Duration `yaml:"period"`
+			Interval         time.Duration `yaml:"interval"`
+			BatchSize        int           `yaml:"batch_size"`
+			Archive          string        `yaml:"archive"`
+			ArchiveDir       string        `yaml:"archive_dir"`
+			PartitionsAhead  int           `yaml:"partitions_ahead"`
+			DetachPartitions bool          `yaml:"detach_partitions"`
+		} `yaml:"retention"`
+	} `yaml:"outbox"`
 }
 
-func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
+// TopicSpec은 프로비저닝할 Kafka 토픽의 선언입니다. 0이나 빈 값인 항목은 확인하지 않습니다.
+// Retention은 retention.ms로 설정되며 음수이면 무기한 보존하고, Config는 그 밖의 토픽 설정입니다.
+type TopicSpec struct {
+	Name              string            `yaml:"name"`
+	Partitions        int32             `yaml:"partitions"`
+	ReplicationFactor int16             `yaml:"replication_factor"`
+	CleanupPolicy     string            `yaml:"cleanup_policy"`
+	Retention         time.Duration     `yaml:"retention"`
+	Config            map[string]string `yaml:"config"`
+}
+
+// EventSpec은 이벤트 카탈로그의 항목입니다. Type은 type 헤더로 쓰이는 이벤트 이름이고 Proto는
+// 메시지의 proto 전체 이름(예: events.AppInstallEvent)입니다. Topic을 비우면 kafka.topics.app_events를,
+// Subject를 비우면 <topic>-<type>을 사용합니다. KeyField를 지정하면 aggregate ID 대신 해당 필드 값을
+// 메시지 키로 사용합니다. Filter를 지정하면 식을 모두 만족하는 이벤트만 핸들러에 전달하며, 식의 문법은
+// events.ParsePredicate를 따릅니다.
+type EventSpec struct {
+	Type     string   `yaml:"type"`
+	Proto    string   `yaml:"proto"`
+	Topic    string   `yaml:"topic"`
+	Subject  string   `yaml:"subject"`
+	KeyField string   `yaml:"key_field"`
+	Filter   []string `yaml:"filter"`
+}
+
+// Load는 설정 파일을 읽고 profile이 있으면 같은 디렉터리의 config.<profile>.yml을 덮어씁니다.
+// 이후 환경 변수 재정의와 기본값을 적용하고 opts와 함께 Validate로 검증합니다.
+func Load(path, profile string, opts ...ValidateOption) (*Config, error) {
+	var config Config
+	if err := decodeFile(path, &config); err != nil {
 		return nil, err
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	// 프로필 파일에 있는 항목만 바뀌며, 목록은 항목별로 합치지 않고 통째로 바뀝니다.
+	if profile != "" {
+		if err := decodeFile(profilePath(path, profile), &config); err != nil {
+			return nil, fmt.Errorf("failed to load profile %s: %w", profile, err)
+		}
+	}
+
+	if err := applyEnv(&config); err != nil {
 		return nil, err
 	}
+	config.applyDefaults()
 
+	if err := config.Validate(opts...); err != nil {
+		return nil, err
+	}
 	return &config, nil
 }
+
+func decodeFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// profilePath는 config/config.yml과 prod에 대해 config/config.prod.yml을 반환합니다.
+func profilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
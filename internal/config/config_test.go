@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+const baseConfig = `
+kafka:
+  brokers: [localhost:9092]
+  consumer:
+    group_id: app-events-group
+  topics:
+    app_events: app.events
+schema_registry:
+  url: http://localhost:8081
+events:
+  - type: AppInstallEvent
+    proto: events.AppInstallEvent
+outbox:
+  dsn: postgres://localhost/app
+`
+
+func TestLoad_RepositoryConfig(t *testing.T) {
+	for _, profile := range []string{"", "prod"} {
+		cfg, err := Load("../../config/config.yml", profile, RequireOutbox())
+		require.NoError(t, err, "profile %q", profile)
+		assert.NotEmpty(t, cfg.Kafka.Brokers)
+	}
+}
+
+func TestLoad_ProfileEnvAndDefaults(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "config.yml", baseConfig)
+	writeConfig(t, dir, "config.staging.yml", `
+kafka:
+  consumer:
+    group_id: staging-group
+outbox:
+  relay:
+    batch_size: 50
+`)
+	t.Setenv("KAFKA_BROKERS", "broker-1:9092, broker-2:9092")
+	t.Setenv("OUTBOX_RELAY_POLL_INTERVAL", "5s")
+
+	cfg, err := Load(path, "staging")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"broker-1:9092", "broker-2:9092"}, cfg.Kafka.Brokers)
+	assert.Equal(t, "staging-group", cfg.Kafka.Consumer.GroupID)
+	assert.Equal(t, "app.events", cfg.Kafka.Topics.AppEvents)
+	assert.Equal(t, 50, cfg.Outbox.Relay.BatchSize)
+	assert.Equal(t, 5*time.Second, cfg.Outbox.Relay.PollInterval)
+	assert.Equal(t, "postgres", cfg.Outbox.Driver)
+	assert.Equal(t, "poll", cfg.Outbox.Relay.Mode)
+	assert.Equal(t, 30*time.Second, cfg.Outbox.Relay.ClaimLease)
+	assert.Equal(t, "app.events", cfg.Events[0].Topic)
+	assert.Equal(t, "app.events-AppInstallEvent", cfg.Events[0].Subject)
+}
+
+func TestLoad_MissingProfile(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yml", baseConfig)
+
+	_, err := Load(path, "qa")
+	assert.ErrorContains(t, err, "failed to load profile qa")
+}
+
+func TestLoad_InvalidEnvOverride(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yml", baseConfig)
+	t.Setenv("OUTBOX_RELAY_BATCH_SIZE", "many")
+
+	_, err := Load(path, "")
+	assert.ErrorContains(t, err, "OUTBOX_RELAY_BATCH_SIZE (outbox.relay.batch_size)")
+}
+
+func TestValidate_ReportsAllFields(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yml", `
+kafka:
+  brokers: [localhost]
+  sasl:
+    mechanism: GSSAPI
+  consumer:
+    rebalance_strategy: cooperative-sticky
+  provisioning:
+    topics:
+      - name: app.events
+      - name: app.events
+schema_registry:
+  url: localhost:8081
+outbox:
+  driver: oracle
+  relay:
+    mode: cdc
+`)
+
+	_, err := Load(path, "", RequireOutbox())
+	var validationErr ValidationError
+	require.True(t, errors.As(err, &validationErr))
+
+	var paths []string
+	for _, fieldErr := range validationErr {
+		paths = append(paths, fieldErr.Path)
+	}
+	assert.Equal(t, []string{
+		"kafka.brokers[0]",
+		"kafka.sasl.mechanism",
+		"kafka.sasl.username",
+		"kafka.sasl.password",
+		"kafka.consumer.group_id",
+		"kafka.consumer.rebalance_strategy",
+		"kafka.topics.app_events",
+		"kafka.provisioning.topics[1].name",
+		"schema_registry.url",
+		"events",
+		"outbox.driver",
+		"outbox.dsn",
+		"outbox.relay.mode",
+	}, paths)
+}
+
+func TestValidate_OutboxOnlyWhenRequired(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yml", `
+kafka:
+  brokers: [localhost:9092]
+  consumer:
+    group_id: app-events-group
+  topics:
+    app_events: app.events
+schema_registry:
+  url: http://localhost:8081
+events:
+  - type: AppInstallEvent
+    proto: events.AppInstallEvent
+`)
+
+	// 컨슈머처럼 outbox를 사용하지 않는 바이너리는 outbox.dsn 없이도 설정을 읽습니다.
+	_, err := Load(path, "")
+	require.NoError(t, err)
+
+	_, err = Load(path, "", RequireOutbox())
+	var validationErr ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, ValidationError{{Path: "outbox.dsn", Message: "is required"}}, validationErr)
+}
+
+func TestRestartRequired(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yml", baseConfig)
+	old, err := Load(path, "")
+	require.NoError(t, err)
+
+	next, err := Load(path, "")
+	require.NoError(t, err)
+	next.Log.Level = "debug"
+	next.Handlers.Disabled = []string{"AppInstallEvent"}
+	next.Handlers.RateLimit.PerSecond = 10
+	assert.Equal(t, []string{"log.level", "handlers.disabled", "handlers.rate_limit.per_second"}, Changed(old, next))
+	assert.Empty(t, RestartRequired(old, next))
+
+	next.Kafka.Brokers = []string{"broker-1:9092"}
+	next.Kafka.Consumer.GroupID = "other-group"
+	assert.Equal(t, []string{"kafka.brokers", "kafka.consumer.group_id"}, RestartRequired(old, next))
+}
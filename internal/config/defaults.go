@@ -0,0 +1,58 @@
+package config
+
+import "time"
+
+// applyDefaults는 0이 허용되지 않는 항목이 비어 있으면 기본값을 채웁니다.
+// 0에 의미가 있는 항목(retry.max_attempts, retention.period 등)은 그대로 둡니다.
+func (c *Config) applyDefaults() {
+	setDefault(&c.Log.Level, "info")
+	setDefault(&c.Handlers.UnknownType, "metric")
+	setDefault(&c.Handlers.Retry.MaxAttempts, 1)
+	setDefault(&c.Handlers.Retry.InitialBackoff, 100*time.Millisecond)
+	setDefault(&c.Handlers.Retry.MaxBackoff, 5*time.Second)
+	setDefault(&c.Handlers.Batch.MaxSize, 100)
+	setDefault(&c.Handlers.Batch.MaxAge, time.Second)
+	if c.Handlers.RateLimit.PerSecond > 0 {
+		setDefault(&c.Handlers.RateLimit.Burst, 1)
+	}
+	if c.Handlers.CircuitBreaker.FailureThreshold > 0 {
+		setDefault(&c.Handlers.CircuitBreaker.OpenDuration, 30*time.Second)
+	}
+
+	setDefault(&c.DLQ.AuditLog, "./dlq-audit.jsonl")
+
+	setDefault(&c.Kafka.Consumer.ShutdownTimeout, 30*time.Second)
+	setDefault(&c.Kafka.Consumer.Concurrency.Default, 1)
+
+	for i := range c.Events {
+		event := &c.Events[i]
+		setDefault(&event.Topic, c.Kafka.Topics.AppEvents)
+		setDefault(&event.Subject, event.Topic+"-"+event.Type)
+	}
+
+	outbox := &c.Outbox
+	setDefault(&outbox.Driver, "postgres")
+
+	relay := &outbox.Relay
+	setDefault(&relay.Mode, "poll")
+	setDefault(&relay.BatchSize, 100)
+	setDefault(&relay.PollInterval, 30*time.Second)
+	setDefault(&relay.ClaimLease, 30*time.Second)
+	setDefault(&relay.Retry.InitialBackoff, time.Second)
+	setDefault(&relay.Retry.MaxBackoff, 5*time.Minute)
+	setDefault(&relay.CDC.Slot, "event_outbox_relay")
+	setDefault(&relay.CDC.Publication, "event_outbox_pub")
+	setDefault(&relay.CDC.StatusInterval, 10*time.Second)
+
+	retention := &outbox.Retention
+	setDefault(&retention.Interval, time.Hour)
+	setDefault(&retention.BatchSize, 1000)
+	setDefault(&retention.ArchiveDir, "./archive")
+}
+
+func setDefault[T comparable](field *T, value T) {
+	var zero T
+	if *field == zero {
+		*field = value
+	}
+}
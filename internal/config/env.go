@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv는 YAML 경로를 대문자와 밑줄로 바꾼 이름의 환경 변수로 설정 값을 재정의합니다.
+// 예를 들어 kafka.brokers는 KAFKA_BROKERS(쉼표로 구분), outbox.relay.batch_size는
+// OUTBOX_RELAY_BATCH_SIZE입니다. 비어 있는 환경 변수는 무시하며, 목록 중 구조체 목록과 맵은
+// 재정의할 수 없습니다.
+func applyEnv(config *Config) error {
+	return applyEnvValue(reflect.ValueOf(config).Elem(), "", "")
+}
+
+func applyEnvValue(v reflect.Value, envName, path string) error {
+	if v.Kind() == reflect.Struct {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			if err := applyEnvValue(v.Field(i), joinEnv(envName, name), joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	value, ok := os.LookupEnv(envName)
+	if !ok || value == "" {
+		return nil
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s (%s): %w", envName, path, err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s (%s): %w", envName, path, err)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s (%s): %w", envName, path, err)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s (%s): %w", envName, path, err)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	}
+	return nil
+}
+
+func joinEnv(prefix, name string) string {
+	name = strings.ToUpper(name)
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
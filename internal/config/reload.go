@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// reloadable은 재시작 없이 적용할 수 있는 항목의 YAML 경로입니다.
+var reloadable = []string{"log", "handlers"}
+
+// Changed는 두 설정에서 값이 다른 항목의 YAML 경로를 반환합니다.
+// 목록은 항목별로 비교하지 않고 목록 전체의 경로를 반환합니다.
+func Changed(old, new *Config) []string {
+	var paths []string
+	diffValue(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), "", &paths)
+	return paths
+}
+
+// RestartRequired는 바뀐 항목 중 재시작해야 적용되는 항목의 YAML 경로를 반환합니다.
+func RestartRequired(old, new *Config) []string {
+	var paths []string
+	for _, path := range Changed(old, new) {
+		if !isReloadable(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func isReloadable(path string) bool {
+	for _, prefix := range reloadable {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func diffValue(a, b reflect.Value, path string, paths *[]string) {
+	if a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*paths = append(*paths, path)
+		}
+		return
+	}
+
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		diffValue(a.Field(i), b.Field(i), joinPath(path, name), paths)
+	}
+}
@@ -0,0 +1,318 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// FieldError는 잘못된 설정 항목 하나입니다. Path는 kafka.consumer.group_id 같은 YAML 경로입니다.
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError는 Validate가 찾은 잘못된 항목을 모두 담습니다.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	lines := make([]string, len(e))
+	for i, fieldErr := range e {
+		lines[i] = fieldErr.Error()
+	}
+	return "invalid config:\n  " + strings.Join(lines, "\n  ")
+}
+
+type validator struct {
+	errs ValidationError
+}
+
+func (v *validator) add(path, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(path, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(path, "is required")
+	}
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(path, "unsupported value %q, must be one of %s", value, strings.Join(allowed, ", "))
+}
+
+func (v *validator) nonNegative(path string, value int64) {
+	if value < 0 {
+		v.add(path, "must not be negative")
+	}
+}
+
+func (v *validator) tls(path string, t TLS) {
+	if !t.Enabled {
+		return
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		v.add(path+".cert_file", "cert_file and key_file must be set together")
+	}
+}
+
+// ValidateOption은 바이너리마다 필요한 검증 항목을 추가합니다.
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	outbox bool
+}
+
+// RequireOutbox는 outbox 항목도 확인합니다. outbox를 사용하는 relay와 publisher만 지정하며,
+// 컨슈머처럼 outbox를 사용하지 않는 바이너리는 outbox.dsn이 없어도 시작할 수 있습니다.
+func RequireOutbox() ValidateOption {
+	return func(o *validateOptions) {
+		o.outbox = true
+	}
+}
+
+// Validate는 모든 항목을 확인하고 잘못된 항목이 있으면 ValidationError를 반환합니다.
+// outbox 항목은 RequireOutbox를 지정한 경우에만 확인합니다.
+// sarama 설정과의 호환성처럼 클라이언트를 만들 때 확인하는 항목은 여기서 다루지 않습니다.
+func (c *Config) Validate(opts ...ValidateOption) error {
+	var options validateOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	v := &validator{}
+	c.validateHandlers(v)
+	c.validateKafka(v)
+	c.validateSchemaRegistry(v)
+	c.validateEvents(v)
+	if options.outbox {
+		c.validateOutbox(v)
+	}
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+func (c *Config) validateHandlers(v *validator) {
+	v.oneOf("log.level", c.Log.Level, "debug", "info", "warn", "error")
+
+	v.oneOf("handlers.unknown_type", c.Handlers.UnknownType, "skip", "metric", "dlq", "halt")
+	v.nonNegative("handlers.timeout", int64(c.Handlers.Timeout))
+	eventTypes := make(map[string]bool, len(c.Events))
+	for _, event := range c.Events {
+		eventTypes[event.Type] = true
+	}
+	timeoutTypes := make([]string, 0, len(c.Handlers.Timeouts))
+	for eventType := range c.Handlers.Timeouts {
+		timeoutTypes = append(timeoutTypes, eventType)
+	}
+	sort.Strings(timeoutTypes)
+	for _, eventType := range timeoutTypes {
+		path := "handlers.timeouts." + eventType
+		if !eventTypes[eventType] {
+			v.add(path, "unknown event type %s", eventType)
+		}
+		v.nonNegative(path, int64(c.Handlers.Timeouts[eventType]))
+	}
+
+	retry := c.Handlers.Retry
+	if retry.MaxAttempts <= 0 {
+		v.add("handlers.retry.max_attempts", "must be positive")
+	}
+	if retry.InitialBackoff > retry.MaxBackoff {
+		v.add("handlers.retry.initial_backoff", "must not exceed max_backoff")
+	}
+	if c.Handlers.Batch.MaxSize <= 0 {
+		v.add("handlers.batch.max_size", "must be positive")
+	}
+	if c.Handlers.Batch.MaxAge <= 0 {
+		v.add("handlers.batch.max_age", "must be positive")
+	}
+	v.nonNegative("handlers.rate_limit.burst", int64(c.Handlers.RateLimit.Burst))
+	if c.Handlers.RateLimit.PerSecond < 0 {
+		v.add("handlers.rate_limit.per_second", "must not be negative")
+	}
+
+	breaker := c.Handlers.CircuitBreaker
+	v.nonNegative("handlers.circuit_breaker.failure_threshold", int64(breaker.FailureThreshold))
+	v.nonNegative("handlers.circuit_breaker.open_duration", int64(breaker.OpenDuration))
+	dependencyTypes := make([]string, 0, len(breaker.Dependencies))
+	for eventType := range breaker.Dependencies {
+		dependencyTypes = append(dependencyTypes, eventType)
+	}
+	sort.Strings(dependencyTypes)
+	for _, eventType := range dependencyTypes {
+		path := "handlers.circuit_breaker.dependencies." + eventType
+		if !eventTypes[eventType] {
+			v.add(path, "unknown event type %s", eventType)
+		}
+		v.required(path, breaker.Dependencies[eventType])
+	}
+
+	if c.Admin.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.Admin.Addr); err != nil {
+			v.add("admin.addr", "must be host:port, got %q", c.Admin.Addr)
+		}
+	}
+}
+
+func (c *Config) validateKafka(v *validator) {
+	kafka := c.Kafka
+	if len(kafka.Brokers) == 0 {
+		v.add("kafka.brokers", "at least one broker is required")
+	}
+	for i, broker := range kafka.Brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			v.add(fmt.Sprintf("kafka.brokers[%d]", i), "must be host:port, got %q", broker)
+		}
+	}
+
+	v.tls("kafka.tls", kafka.TLS)
+	if kafka.SASL.Mechanism != "" {
+		v.oneOf("kafka.sasl.mechanism", kafka.SASL.Mechanism, "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512")
+		v.required("kafka.sasl.username", kafka.SASL.Username)
+		if !kafka.SASL.Password.IsSet() {
+			v.add("kafka.sasl.password", "file or env is required")
+		}
+	}
+
+	consumer := kafka.Consumer
+	v.required("kafka.consumer.group_id", consumer.GroupID)
+	if consumer.AutoOffsetReset != "" {
+		v.oneOf("kafka.consumer.auto_offset_reset", consumer.AutoOffsetReset, "oldest", "earliest", "newest", "latest")
+	}
+	if consumer.RebalanceStrategy != "" {
+		v.oneOf("kafka.consumer.rebalance_strategy", consumer.RebalanceStrategy, "range", "roundrobin", "sticky")
+	}
+	if consumer.IsolationLevel != "" {
+		v.oneOf("kafka.consumer.isolation_level", consumer.IsolationLevel, "read_committed", "read_uncommitted")
+	}
+	v.nonNegative("kafka.consumer.session_timeout", int64(consumer.SessionTimeout))
+	v.nonNegative("kafka.consumer.heartbeat_interval", int64(consumer.HeartbeatInterval))
+	if consumer.SessionTimeout > 0 && consumer.HeartbeatInterval >= consumer.SessionTimeout {
+		v.add("kafka.consumer.heartbeat_interval", "must be less than session_timeout")
+	}
+	v.nonNegative("kafka.consumer.shutdown_timeout", int64(consumer.ShutdownTimeout))
+	v.nonNegative("kafka.consumer.concurrency.default", int64(consumer.Concurrency.Default))
+	concurrencyTopics := make([]string, 0, len(consumer.Concurrency.Topics))
+	for topic := range consumer.Concurrency.Topics {
+		concurrencyTopics = append(concurrencyTopics, topic)
+	}
+	sort.Strings(concurrencyTopics)
+	for _, topic := range concurrencyTopics {
+		v.nonNegative("kafka.consumer.concurrency.topics."+topic, int64(consumer.Concurrency.Topics[topic]))
+	}
+	v.nonNegative("kafka.consumer.fetch.min_bytes", int64(consumer.Fetch.MinBytes))
+	v.nonNegative("kafka.consumer.fetch.default_bytes", int64(consumer.Fetch.DefaultBytes))
+	v.nonNegative("kafka.consumer.fetch.max_bytes", int64(consumer.Fetch.MaxBytes))
+	v.nonNegative("kafka.consumer.fetch.max_wait", int64(consumer.Fetch.MaxWait))
+
+	v.required("kafka.topics.app_events", kafka.Topics.AppEvents)
+
+	seen := make(map[string]bool)
+	for i, spec := range kafka.Provisioning.Topics {
+		path := fmt.Sprintf("kafka.provisioning.topics[%d]", i)
+		if spec.Name == "" {
+			v.add(path+".name", "is required")
+		} else if seen[spec.Name] {
+			v.add(path+".name", "duplicate topic %s", spec.Name)
+		}
+		seen[spec.Name] = true
+		v.nonNegative(path+".partitions", int64(spec.Partitions))
+		v.nonNegative(path+".replication_factor", int64(spec.ReplicationFactor))
+		if spec.CleanupPolicy != "" {
+			v.oneOf(path+".cleanup_policy", spec.CleanupPolicy, "delete", "compact", "compact,delete", "delete,compact")
+		}
+	}
+}
+
+func (c *Config) validateSchemaRegistry(v *validator) {
+	registry := c.SchemaRegistry
+	if registry.URL == "" {
+		v.add("schema_registry.url", "is required")
+	} else if u, err := url.Parse(registry.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add("schema_registry.url", "must be an http or https URL, got %q", registry.URL)
+	}
+
+	v.tls("schema_registry.tls", registry.TLS)
+	if registry.Auth.Username != "" && !registry.Auth.Password.IsSet() {
+		v.add("schema_registry.auth.password", "file or env is required with username")
+	}
+}
+
+// validateEvents는 카탈로그의 형식만 확인합니다. proto 이름과 key_field는 메시지 타입이 등록된
+// 바이너리에서 카탈로그를 만들 때 확인합니다.
+func (c *Config) validateEvents(v *validator) {
+	if len(c.Events) == 0 {
+		v.add("events", "at least one event is required")
+	}
+	seen := make(map[string]bool)
+	for i, event := range c.Events {
+		path := fmt.Sprintf("events[%d]", i)
+		if event.Type == "" {
+			v.add(path+".type", "is required")
+		} else if seen[event.Type] {
+			v.add(path+".type", "duplicate event type %s", event.Type)
+		}
+		seen[event.Type] = true
+		v.required(path+".proto", event.Proto)
+	}
+}
+
+func (c *Config) validateOutbox(v *validator) {
+	outbox := c.Outbox
+	v.oneOf("outbox.driver", outbox.Driver, "postgres", "mysql", "sqlite")
+	v.required("outbox.dsn", outbox.DSN)
+
+	relay := outbox.Relay
+	v.oneOf("outbox.relay.mode", relay.Mode, "poll", "cdc")
+	if relay.Mode == "cdc" {
+		if outbox.Driver != "postgres" {
+			v.add("outbox.relay.mode", "cdc requires the postgres driver")
+		}
+		if relay.TransactionalID != "" {
+			v.add("outbox.relay.transactional_id", "is not supported in cdc mode")
+		}
+	}
+	if relay.BatchSize <= 0 {
+		v.add("outbox.relay.batch_size", "must be positive")
+	}
+	if relay.PollInterval <= 0 {
+		v.add("outbox.relay.poll_interval", "must be positive")
+	}
+	if relay.ClaimLease <= 0 {
+		v.add("outbox.relay.claim_lease", "must be positive")
+	}
+	v.nonNegative("outbox.relay.retry.max_attempts", int64(relay.Retry.MaxAttempts))
+	if relay.Retry.InitialBackoff > relay.Retry.MaxBackoff {
+		v.add("outbox.relay.retry.initial_backoff", "must not exceed max_backoff")
+	}
+	if relay.CDC.StatusInterval <= 0 {
+		v.add("outbox.relay.cdc.status_interval", "must be positive")
+	}
+
+	retention := outbox.Retention
+	v.nonNegative("outbox.retention.period", int64(retention.Period))
+	if retention.Interval <= 0 {
+		v.add("outbox.retention.interval", "must be positive")
+	}
+	if retention.BatchSize <= 0 {
+		v.add("outbox.retention.batch_size", "must be positive")
+	}
+	if retention.Archive != "" {
+		v.oneOf("outbox.retention.archive", retention.Archive, "none", "table", "jsonl")
+	}
+	v.nonNegative("outbox.retention.partitions_ahead", int64(retention.PartitionsAhead))
+}
@@ -0,0 +1,69 @@
+package dlq
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 감사 로그의 작업 이름입니다.
+const (
+	ActionRedrive = "redrive"
+	ActionPurge   = "purge"
+)
+
+// AuditEntry는 감사 로그의 한 줄입니다. purge는 파티션마다 한 줄이며, Offset 앞의 메시지를 지웠음을 나타냅니다.
+type AuditEntry struct {
+	Time            time.Time `json:"time"`
+	Operator        string    `json:"operator"`
+	Action          string    `json:"action"`
+	DLQTopic        string    `json:"dlq_topic"`
+	Partition       int32     `json:"partition"`
+	Offset          int64     `json:"offset"`
+	EventType       string    `json:"type,omitempty"`
+	Target          string    `json:"target,omitempty"`
+	TargetPartition int32     `json:"target_partition,omitempty"`
+	TargetOffset    int64     `json:"target_offset,omitempty"`
+}
+
+// AuditLog는 DLQ 작업을 JSONL 파일에 덧붙여 기록합니다.
+type AuditLog struct {
+	path     string
+	operator string
+	now      func() time.Time
+}
+
+func NewAuditLog(path, operator string) *AuditLog {
+	return &AuditLog{
+		path:     path,
+		operator: operator,
+		now:      time.Now,
+	}
+}
+
+// Record는 entries에 시각과 작업자를 채워 감사 로그에 덧붙이고, 반환하기 전에 디스크에 씁니다.
+func (a *AuditLog) Record(entries ...AuditEntry) error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, entry := range entries {
+		entry.Time = a.now().UTC()
+		entry.Operator = a.operator
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+	return f.Close()
+}
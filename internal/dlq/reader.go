@@ -0,0 +1,134 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// errStopScan은 파티션을 끝까지 읽지 않고 멈출 때 사용합니다.
+var errStopScan = errors.New("stop scan")
+
+// Reader는 DLQ 토픽에 지금 남아 있는 메시지를 처음부터 읽습니다.
+type Reader struct {
+	client sarama.Client
+	topic  string
+}
+
+func NewReader(client sarama.Client, topic string) *Reader {
+	return &Reader{
+		client: client,
+		topic:  topic,
+	}
+}
+
+// Scan은 파티션마다 Scan을 호출한 시점의 마지막 메시지까지 읽어 filter와 일치하는 레코드마다 fn을 호출합니다.
+// 트랜잭션 마커처럼 전달되지 않는 레코드가 끝에 있으면 ctx가 끝날 때까지 기다리므로 ctx에 제한 시간을 두어야 합니다.
+func (r *Reader) Scan(ctx context.Context, filter Filter, fn func(Record) error) error {
+	partitions, err := r.client.Partitions(r.topic)
+	if err != nil {
+		return fmt.Errorf("failed to get partitions of %s: %w", r.topic, err)
+	}
+	for _, partition := range partitions {
+		err := r.scanPartition(ctx, partition, func(record Record) error {
+			if !filter.Match(record) {
+				return nil
+			}
+			return fn(record)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeOffsets는 실패 시각이 before보다 앞선 메시지를 지우기 위해 파티션마다 지울 기준 오프셋을 반환합니다.
+// 토픽의 메시지는 앞에서부터만 지울 수 있으므로, before 이후에 실패한 첫 메시지의 오프셋을 기준으로 합니다.
+// before가 비어 있으면 파티션의 모든 메시지를 지웁니다. 지울 메시지가 없는 파티션은 포함하지 않습니다.
+func (r *Reader) PurgeOffsets(ctx context.Context, before time.Time) (map[int32]int64, error) {
+	partitions, err := r.client.Partitions(r.topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partitions of %s: %w", r.topic, err)
+	}
+
+	offsets := make(map[int32]int64)
+	for _, partition := range partitions {
+		oldest, newest, err := r.offsetRange(partition)
+		if err != nil {
+			return nil, err
+		}
+		offset := newest
+		if !before.IsZero() {
+			err := r.scanPartition(ctx, partition, func(record Record) error {
+				if !record.FailedAt.Before(before) {
+					offset = record.Offset
+					return errStopScan
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if offset > oldest {
+			offsets[partition] = offset
+		}
+	}
+	return offsets, nil
+}
+
+func (r *Reader) offsetRange(partition int32) (int64, int64, error) {
+	oldest, err := r.client.GetOffset(r.topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get oldest offset of %s/%d: %w", r.topic, partition, err)
+	}
+	newest, err := r.client.GetOffset(r.topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get newest offset of %s/%d: %w", r.topic, partition, err)
+	}
+	return oldest, newest, nil
+}
+
+func (r *Reader) scanPartition(ctx context.Context, partition int32, fn func(Record) error) error {
+	oldest, newest, err := r.offsetRange(partition)
+	if err != nil {
+		return err
+	}
+	if oldest >= newest {
+		return nil
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(r.client)
+	if err != nil {
+		return fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+	pc, err := consumer.ConsumePartition(r.topic, partition, oldest)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s/%d: %w", r.topic, partition, err)
+	}
+	defer pc.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to read %s/%d: %w", r.topic, partition, ctx.Err())
+		case err := <-pc.Errors():
+			return fmt.Errorf("failed to read %s/%d: %w", r.topic, partition, err)
+		case msg := <-pc.Messages():
+			if err := fn(NewRecord(msg)); err != nil {
+				if errors.Is(err, errStopScan) {
+					return nil
+				}
+				return err
+			}
+			if msg.Offset >= newest-1 {
+				return nil
+			}
+		}
+	}
+}
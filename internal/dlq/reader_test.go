@@ -0,0 +1,125 @@
+package dlq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/kafka"
+)
+
+const testTopic = "app.events.dlq"
+
+// failedMessage는 MockBroker가 돌려줄 DLQ 메시지입니다.
+type failedMessage struct {
+	partition int32
+	offset    int64
+	failedAt  time.Time
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC)
+}
+
+// newTestReader는 파티션마다 [oldest, newest) 오프셋 범위와 messages를 돌려주는 MockBroker에 연결된
+// Reader를 만듭니다. 범위 밖의 메시지도 fetch 응답에 담아 Scan 이후에 추가된 메시지처럼 다룰 수 있습니다.
+func newTestReader(t *testing.T, ranges map[int32][2]int64, messages []failedMessage) *Reader {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	metadata := sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID())
+	offsets := sarama.NewMockOffsetResponse(t)
+	for partition, r := range ranges {
+		metadata.SetLeader(testTopic, partition, broker.BrokerID())
+		offsets.SetOffset(testTopic, partition, sarama.OffsetOldest, r[0])
+		offsets.SetOffset(testTopic, partition, sarama.OffsetNewest, r[1])
+	}
+
+	fetch := &sarama.FetchResponse{Version: 5}
+	for _, m := range messages {
+		fetch.AddRecordWithTimestamp(testTopic, m.partition, nil, sarama.StringEncoder("payload"), m.offset, m.failedAt)
+		batch := fetch.GetBlock(testTopic, m.partition).RecordsSet[0].RecordBatch
+		record := batch.Records[len(batch.Records)-1]
+		record.Headers = []*sarama.RecordHeader{
+			header("type", "AppInstallEvent"),
+			header(kafka.HeaderDLQFailedAt, m.failedAt.Format(time.RFC3339)),
+		}
+	}
+	for partition, r := range ranges {
+		block := fetch.GetBlock(testTopic, partition)
+		if block == nil {
+			fetch.AddError(testTopic, partition, sarama.ErrNoError)
+			block = fetch.GetBlock(testTopic, partition)
+		}
+		block.HighWaterMarkOffset = r[1]
+	}
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadata,
+		"OffsetRequest":   offsets,
+		"FetchRequest":    sarama.NewMockWrapper(fetch),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V0_11_0_0
+	config.Consumer.Return.Errors = true
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return NewReader(client, testTopic)
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestReader_ScanStopsAtHighWatermark(t *testing.T) {
+	// 오프셋 3은 Scan을 시작한 뒤 들어온 메시지이므로 읽지 않고, 비어 있는 파티션 1은 기다리지 않습니다.
+	reader := newTestReader(t, map[int32][2]int64{0: {0, 3}, 1: {2, 2}}, []failedMessage{
+		{partition: 0, offset: 0, failedAt: day(1)},
+		{partition: 0, offset: 1, failedAt: day(2)},
+		{partition: 0, offset: 2, failedAt: day(3)},
+		{partition: 0, offset: 3, failedAt: day(4)},
+	})
+
+	var offsets []int64
+	err := reader.Scan(testContext(t), Filter{Since: day(2)}, func(r Record) error {
+		offsets = append(offsets, r.Offset)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, offsets)
+}
+
+func TestReader_PurgeOffsets(t *testing.T) {
+	reader := newTestReader(t, map[int32][2]int64{
+		0: {0, 3},
+		1: {5, 7},
+		2: {4, 4},
+	}, []failedMessage{
+		{partition: 0, offset: 0, failedAt: day(1)},
+		{partition: 0, offset: 1, failedAt: day(2)},
+		{partition: 0, offset: 2, failedAt: day(3)},
+		{partition: 1, offset: 5, failedAt: day(5)},
+		{partition: 1, offset: 6, failedAt: day(6)},
+	})
+	ctx := testContext(t)
+
+	// 파티션 0은 until 이후에 실패한 첫 메시지 앞까지 지우고, 첫 메시지부터 until 이후인 파티션 1과
+	// 비어 있는 파티션 2는 지우지 않습니다.
+	offsets, err := reader.PurgeOffsets(ctx, day(2).Add(12*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int64{0: 2}, offsets)
+
+	// until이 없으면 비어 있지 않은 파티션을 모두 지웁니다.
+	offsets, err = reader.PurgeOffsets(ctx, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, map[int32]int64{0: 3, 1: 7}, offsets)
+}
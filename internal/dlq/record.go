@@ -0,0 +1,111 @@
+package dlq
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/kafka"
+)
+
+// Record는 DLQ 토픽의 메시지 하나와 컨슈머가 추가한 실패 헤더입니다.
+type Record struct {
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []*sarama.RecordHeader
+	Timestamp time.Time
+
+	EventType         string
+	Error             string
+	Reason            string
+	OriginalTopic     string
+	OriginalPartition int32
+	OriginalOffset    int64
+	// FailedAt은 dlq.failed_at 헤더의 시각이며, 헤더가 없으면 메시지의 타임스탬프입니다.
+	FailedAt time.Time
+}
+
+// NewRecord는 DLQ 토픽에서 읽은 메시지의 헤더를 해석합니다. 형식이 잘못된 헤더는 비워 둡니다.
+func NewRecord(msg *sarama.ConsumerMessage) Record {
+	r := Record{
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   msg.Headers,
+		Timestamp: msg.Timestamp,
+		FailedAt:  msg.Timestamp,
+	}
+	for _, header := range msg.Headers {
+		value := string(header.Value)
+		switch string(header.Key) {
+		case "type":
+			r.EventType = value
+		case kafka.HeaderDLQError:
+			r.Error = value
+		case kafka.HeaderDLQReason:
+			r.Reason = value
+		case kafka.HeaderDLQTopic:
+			r.OriginalTopic = value
+		case kafka.HeaderDLQPartition:
+			if partition, err := strconv.ParseInt(value, 10, 32); err == nil {
+				r.OriginalPartition = int32(partition)
+			}
+		case kafka.HeaderDLQOffset:
+			if offset, err := strconv.ParseInt(value, 10, 64); err == nil {
+				r.OriginalOffset = offset
+			}
+		case kafka.HeaderDLQFailedAt:
+			if failedAt, err := time.Parse(time.RFC3339, value); err == nil {
+				r.FailedAt = failedAt
+			}
+		}
+	}
+	return r
+}
+
+// Position은 DLQ 토픽에서 메시지의 위치입니다.
+type Position struct {
+	Partition int32
+	Offset    int64
+}
+
+// Filter는 DLQ 메시지를 고르는 조건입니다. 비어 있는 조건은 모든 메시지와 일치합니다.
+type Filter struct {
+	EventType string
+	// Error는 실패 메시지에 포함된 문자열입니다.
+	Error string
+	// Since와 Until은 실패 시각의 범위이며, Since는 포함하고 Until은 포함하지 않습니다.
+	Since time.Time
+	Until time.Time
+	// Positions가 있으면 해당 위치의 메시지만 고릅니다.
+	Positions []Position
+}
+
+// Match는 r이 모든 조건과 일치하는지 확인합니다.
+func (f Filter) Match(r Record) bool {
+	if f.EventType != "" && r.EventType != f.EventType {
+		return false
+	}
+	if f.Error != "" && !strings.Contains(r.Error, f.Error) {
+		return false
+	}
+	if !f.Since.IsZero() && r.FailedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.FailedAt.Before(f.Until) {
+		return false
+	}
+	if len(f.Positions) == 0 {
+		return true
+	}
+	for _, position := range f.Positions {
+		if position.Partition == r.Partition && position.Offset == r.Offset {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package dlq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hoo47/kafka_ex/internal/kafka"
+)
+
+func header(key, value string) *sarama.RecordHeader {
+	return &sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
+
+func dlqMessage(offset int64, eventType, failure, failedAt string) *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{
+		Topic:     "app.events.dlq",
+		Partition: 1,
+		Offset:    offset,
+		Key:       []byte("channel-1"),
+		Value:     []byte{0x0, 0x0, 0x0, 0x0, 0x1},
+		Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Headers: []*sarama.RecordHeader{
+			header("type", eventType),
+			header(kafka.HeaderDLQError, failure),
+			header(kafka.HeaderDLQReason, kafka.DLQReasonExhausted),
+			header(kafka.HeaderDLQTopic, "app.events"),
+			header(kafka.HeaderDLQPartition, "3"),
+			header(kafka.HeaderDLQOffset, "42"),
+			header(kafka.HeaderDLQFailedAt, failedAt),
+		},
+	}
+}
+
+func TestNewRecord(t *testing.T) {
+	r := NewRecord(dlqMessage(7, "AppInstallEvent", "db timeout", "2024-03-01T10:00:00Z"))
+
+	assert.Equal(t, int32(1), r.Partition)
+	assert.Equal(t, int64(7), r.Offset)
+	assert.Equal(t, "AppInstallEvent", r.EventType)
+	assert.Equal(t, "db timeout", r.Error)
+	assert.Equal(t, kafka.DLQReasonExhausted, r.Reason)
+	assert.Equal(t, "app.events", r.OriginalTopic)
+	assert.Equal(t, int32(3), r.OriginalPartition)
+	assert.Equal(t, int64(42), r.OriginalOffset)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), r.FailedAt)
+
+	// 실패 시각 헤더가 잘못되었으면 메시지의 타임스탬프를 사용합니다.
+	r = NewRecord(dlqMessage(7, "AppInstallEvent", "db timeout", "yesterday"))
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), r.FailedAt)
+}
+
+func TestFilter_Match(t *testing.T) {
+	r := NewRecord(dlqMessage(7, "AppInstallEvent", "permanent failure: invalid channel", "2024-03-01T10:00:00Z"))
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "empty", filter: Filter{}, want: true},
+		{name: "event type", filter: Filter{EventType: "AppInstallEvent"}, want: true},
+		{name: "other event type", filter: Filter{EventType: "AppUninstallEvent"}, want: false},
+		{name: "error text", filter: Filter{Error: "invalid channel"}, want: true},
+		{name: "other error text", filter: Filter{Error: "timeout"}, want: false},
+		{name: "since inclusive", filter: Filter{Since: r.FailedAt}, want: true},
+		{name: "until exclusive", filter: Filter{Until: r.FailedAt}, want: false},
+		{name: "position", filter: Filter{Positions: []Position{{Partition: 1, Offset: 7}}}, want: true},
+		{name: "other position", filter: Filter{Positions: []Position{{Partition: 0, Offset: 7}}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(r))
+		})
+	}
+}
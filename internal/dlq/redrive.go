@@ -0,0 +1,82 @@
+package dlq
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// RedriveMessage는 레코드를 다시 처리하도록 보낼 메시지를 만듭니다. topic이 비어 있으면 원래 토픽으로
+// 보냅니다. 원본 키, 값, 헤더는 그대로 두고 컨슈머가 추가한 dlq. 헤더만 제거합니다.
+func RedriveMessage(r Record, topic string) (*sarama.ProducerMessage, error) {
+	if topic == "" {
+		topic = r.OriginalTopic
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("message %d/%d has no original topic header", r.Partition, r.Offset)
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(r.Headers))
+	for _, header := range r.Headers {
+		if strings.HasPrefix(string(header.Key), "dlq.") {
+			continue
+		}
+		headers = append(headers, *header)
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(r.Value),
+		Headers: headers,
+	}
+	if r.Key != nil {
+		msg.Key = sarama.ByteEncoder(r.Key)
+	}
+	return msg, nil
+}
+
+// Redriver는 DLQ 메시지를 다시 보내고 보낸 메시지를 감사 로그에 기록합니다.
+// DLQ 토픽의 메시지는 지우지 않으므로 다시 보낸 뒤에는 Purge로 정리합니다.
+type Redriver struct {
+	producer sarama.SyncProducer
+	audit    *AuditLog
+	dlqTopic string
+}
+
+func NewRedriver(producer sarama.SyncProducer, audit *AuditLog, dlqTopic string) *Redriver {
+	return &Redriver{
+		producer: producer,
+		audit:    audit,
+		dlqTopic: dlqTopic,
+	}
+}
+
+// Redrive는 records를 topic(비어 있으면 원래 토픽)으로 보냅니다. 메시지마다 보낸 직후 감사 로그에
+// 기록하므로 중간에 실패해도 기록은 실제로 보낸 메시지와 일치합니다. 보낸 메시지 수를 반환합니다.
+func (r *Redriver) Redrive(records []Record, topic string) (int, error) {
+	for i, record := range records {
+		msg, err := RedriveMessage(record, topic)
+		if err != nil {
+			return i, err
+		}
+		partition, offset, err := r.producer.SendMessage(msg)
+		if err != nil {
+			return i, fmt.Errorf("failed to redrive message %d/%d: %w", record.Partition, record.Offset, err)
+		}
+		err = r.audit.Record(AuditEntry{
+			Action:          ActionRedrive,
+			DLQTopic:        r.dlqTopic,
+			Partition:       record.Partition,
+			Offset:          record.Offset,
+			EventType:       record.EventType,
+			Target:          msg.Topic,
+			TargetPartition: partition,
+			TargetOffset:    offset,
+		})
+		if err != nil {
+			return i + 1, err
+		}
+	}
+	return len(records), nil
+}
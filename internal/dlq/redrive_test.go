@@ -0,0 +1,70 @@
+package dlq
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedriveMessage(t *testing.T) {
+	r := NewRecord(dlqMessage(7, "AppInstallEvent", "db timeout", "2024-03-01T10:00:00Z"))
+
+	msg, err := RedriveMessage(r, "")
+	require.NoError(t, err)
+	assert.Equal(t, "app.events", msg.Topic)
+	assert.Equal(t, sarama.ByteEncoder("channel-1"), msg.Key)
+	assert.Equal(t, []sarama.RecordHeader{*header("type", "AppInstallEvent")}, msg.Headers)
+
+	msg, err = RedriveMessage(r, "app.events.retry")
+	require.NoError(t, err)
+	assert.Equal(t, "app.events.retry", msg.Topic)
+
+	_, err = RedriveMessage(Record{Offset: 1}, "")
+	assert.Error(t, err)
+}
+
+func TestRedriver_RecordsAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "dlq.jsonl")
+	audit := NewAuditLog(path, "alice")
+	audit.now = func() time.Time { return time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC) }
+
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+
+	records := []Record{
+		NewRecord(dlqMessage(7, "AppInstallEvent", "db timeout", "2024-03-01T10:00:00Z")),
+		NewRecord(dlqMessage(8, "AppInstallEvent", "db timeout", "2024-03-01T10:00:01Z")),
+	}
+	sent, err := NewRedriver(producer, audit, "app.events.dlq").Redrive(records, "")
+	require.NoError(t, err)
+	require.NoError(t, producer.Close())
+	assert.Equal(t, 2, sent)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry AuditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+	assert.Equal(t, ActionRedrive, entries[0].Action)
+	assert.Equal(t, "alice", entries[0].Operator)
+	assert.Equal(t, "app.events.dlq", entries[0].DLQTopic)
+	assert.Equal(t, int64(7), entries[0].Offset)
+	assert.Equal(t, "app.events", entries[0].Target)
+	assert.Equal(t, int64(8), entries[1].Offset)
+}
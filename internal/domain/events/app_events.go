@@ -8,9 +8,9 @@ type AppInstallEvent struct {
 	BaseEvent
 }
 
-func NewAppInstallEvent(aggregateID string, protoMsg *pkgevents.AppInstallEvent) AppInstallEvent {
+func NewAppInstallEvent(aggregateID string, protoMsg *pkgevents.AppInstallEvent, opts ...EventOption) AppInstallEvent {
 	return AppInstallEvent{
-		BaseEvent: NewBaseEvent("app", aggregateID, "AppInstallEvent", protoMsg),
+		BaseEvent: NewBaseEvent("app", aggregateID, "AppInstallEvent", protoMsg, opts...),
 	}
 }
 
@@ -18,8 +18,8 @@ type AppUninstallEvent struct {
 	BaseEvent
 }
 
-func NewAppUninstallEvent(aggregateID string, protoMsg *pkgevents.AppUninstallEvent) AppUninstallEvent {
+func NewAppUninstallEvent(aggregateID string, protoMsg *pkgevents.AppUninstallEvent, opts ...EventOption) AppUninstallEvent {
 	return AppUninstallEvent{
-		BaseEvent: NewBaseEvent("app", aggregateID, "AppUninstallEvent", protoMsg),
+		BaseEvent: NewBaseEvent("app", aggregateID, "AppUninstallEvent", protoMsg, opts...),
 	}
 }
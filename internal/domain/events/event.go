@@ -1,32 +1,76 @@
 package events
 
 import (
+	"time"
+
+	"github.com/google/uuid"
 	"google.golang.org/protobuf/proto"
 )
 
 // Event는 도메인 이벤트를 나타내는 인터페이스입니다.
 type Event interface {
+	// ID는 이벤트를 식별하며, 예약된 이벤트를 취소할 때 사용합니다.
+	ID() string
 	AggregateType() string
 	AggregateID() string
 	Type() string
+	// IdempotencyKey는 같은 이벤트의 재시도를 식별하는 키입니다. 비어 있으면 publisher가 이벤트 ID를
+	// 키로 사용하므로, 같은 ID로 다시 발행한 이벤트만 중복으로 걸러집니다.
+	IdempotencyKey() string
+	// DeliverAt은 이벤트를 발행할 시각입니다. 0이면 바로 발행합니다.
+	DeliverAt() time.Time
 	ToProto() proto.Message
 }
 
+// EventOption은 BaseEvent 생성 시 선택적인 속성을 설정합니다.
+type EventOption func(*BaseEvent)
+
+// WithIdempotencyKey는 이벤트의 멱등성 키를 설정합니다.
+func WithIdempotencyKey(key string) EventOption {
+	return func(e *BaseEvent) {
+		e.idempotencyKey = key
+	}
+}
+
+// WithDeliverAt은 이벤트가 t 이후에 발행되도록 예약합니다.
+func WithDeliverAt(t time.Time) EventOption {
+	return func(e *BaseEvent) {
+		e.deliverAt = t
+	}
+}
+
+// WithDelay는 이벤트가 지금부터 d 이후에 발행되도록 예약합니다.
+func WithDelay(d time.Duration) EventOption {
+	return WithDeliverAt(time.Now().Add(d))
+}
+
 // BaseEvent는 모든 이벤트의 기본 구현을 제공합니다.
 type BaseEvent struct {
-	aggregateType string
-	aggregateID   string
-	eventType     string
-	protoMsg      proto.Message
+	id             string
+	aggregateType  string
+	aggregateID    string
+	eventType      string
+	idempotencyKey string
+	deliverAt      time.Time
+	protoMsg       proto.Message
 }
 
-func NewBaseEvent(aggregateType, aggregateID, eventType string, protoMsg proto.Message) BaseEvent {
-	return BaseEvent{
+func NewBaseEvent(aggregateType, aggregateID, eventType string, protoMsg proto.Message, opts ...EventOption) BaseEvent {
+	e := BaseEvent{
+		id:            uuid.NewString(),
 		aggregateType: aggregateType,
 		aggregateID:   aggregateID,
 		eventType:     eventType,
 		protoMsg:      protoMsg,
 	}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
+}
+
+func (e BaseEvent) ID() string {
+	return e.id
 }
 
 func (e BaseEvent) AggregateType() string {
@@ -41,6 +85,14 @@ func (e BaseEvent) Type() string {
 	return e.eventType
 }
 
+func (e BaseEvent) IdempotencyKey() string {
+	return e.idempotencyKey
+}
+
+func (e BaseEvent) DeliverAt() time.Time {
+	return e.deliverAt
+}
+
 func (e BaseEvent) ToProto() proto.Message {
 	return e.protoMsg
 }
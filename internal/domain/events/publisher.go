@@ -4,11 +4,37 @@ import (
 	"context"
 )
 
+// PublishStatus는 이벤트가 outbox에 어떻게 반영되었는지를 나타냅니다.
+type PublishStatus int
+
+const (
+	// StatusRecorded는 이벤트가 새로 기록되었음을 나타냅니다.
+	StatusRecorded PublishStatus = iota
+	// StatusDuplicate는 같은 멱등성 키의 이벤트가 이미 기록되어 있어 무시되었음을 나타냅니다.
+	StatusDuplicate
+)
+
+func (s PublishStatus) String() string {
+	switch s {
+	case StatusRecorded:
+		return "recorded"
+	case StatusDuplicate:
+		return "duplicate"
+	default:
+		return "unknown"
+	}
+}
+
 // EventPublisher는 이벤트를 발행하는 인터페이스입니다.
 type EventPublisher interface {
 	// Publish는 단일 이벤트를 발행합니다.
-	Publish(ctx context.Context, event Event) error
+	Publish(ctx context.Context, event Event) (PublishStatus, error)
 
 	// PublishAll은 여러 이벤트를 하나의 트랜잭션으로 발행합니다.
-	PublishAll(ctx context.Context, events []Event) error
+	// 반환되는 상태는 입력 이벤트와 같은 순서입니다.
+	PublishAll(ctx context.Context, events []Event) ([]PublishStatus, error)
+
+	// Cancel은 아직 발행되지 않은 예약 이벤트를 취소하고, 취소되었는지를 반환합니다.
+	// 이미 발행 중이거나 발행된 이벤트, 예약되지 않은 이벤트는 취소되지 않습니다.
+	Cancel(ctx context.Context, eventID string) (bool, error)
 }
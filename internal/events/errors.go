@@ -0,0 +1,78 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrHandlerTimeout은 핸들러가 이벤트 타입별 제한 시간 안에 끝나지 않았음을 나타냅니다.
+// errors.Is로 다른 실패와 구분할 수 있습니다.
+var ErrHandlerTimeout = errors.New("handler timed out")
+
+// ErrNoHandler는 이벤트 타입에 등록된 핸들러가 없음을 나타냅니다.
+var ErrNoHandler = errors.New("no handler registered")
+
+// PermanentError는 재시도해도 성공하지 않는 실패입니다. 컨슈머는 재시도하지 않고 바로 DLQ로 보냅니다.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return "permanent failure: " + e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent는 err를 재시도하지 않을 실패로 표시합니다. 검증 실패처럼 메시지 자체가 잘못된 경우에 사용합니다.
+// err가 nil이면 nil을 반환합니다.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent는 err가 재시도하지 않을 실패인지 확인합니다.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// RetryAfterError는 Delay만큼 기다린 뒤 재시도할 실패입니다.
+type RetryAfterError struct {
+	Delay time.Duration
+	Err   error
+}
+
+func (e *RetryAfterError) Error() string {
+	return fmt.Sprintf("retry after %s: %s", e.Delay, e.Err)
+}
+
+func (e *RetryAfterError) Unwrap() error { return e.Err }
+
+// RetryAfter는 err를 재시도 설정의 대기 시간 대신 d만큼 기다린 뒤 재시도할 실패로 표시합니다.
+// 의존 대상이 재시도 시각을 알려 준 경우에 사용하며, 재시도 횟수는 재시도 설정을 따릅니다.
+// err가 nil이면 nil을 반환합니다.
+func RetryAfter(d time.Duration, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryAfterError{Delay: d, Err: err}
+}
+
+// SkipError는 처리하지 않고 건너뛸 메시지를 나타냅니다. 실패로 보지 않으며 DLQ로 보내지 않습니다.
+type SkipError struct {
+	Reason string
+}
+
+func (e *SkipError) Error() string { return "skipped: " + e.Reason }
+
+// Skip은 메시지를 처리하지 않고 끝난 것으로 보게 합니다. 이미 처리한 중복 메시지처럼 처리할 필요가 없는 경우에 사용합니다.
+func Skip(reason string) error {
+	return &SkipError{Reason: reason}
+}
+
+// IsSkip은 err가 건너뛸 메시지를 나타내는지 확인합니다.
+func IsSkip(err error) bool {
+	var skip *SkipError
+	return errors.As(err, &skip)
+}
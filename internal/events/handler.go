@@ -2,6 +2,8 @@ package events
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 )
@@ -10,3 +12,67 @@ type EventHandler interface {
 	EventType() string
 	Handle(context.Context, proto.Message) error
 }
+
+// Message는 배치 핸들러에 전달되는 이벤트와 Kafka 메타데이터입니다.
+type Message struct {
+	Event     proto.Message
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Timestamp time.Time
+}
+
+// RawMessage는 역직렬화하지 않은 메시지와 Kafka 메타데이터입니다.
+type RawMessage struct {
+	Type      string
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	// Headers는 메시지 헤더이며, 같은 키가 여러 번 있으면 마지막 값을 사용합니다.
+	Headers   map[string][]byte
+	Timestamp time.Time
+}
+
+// DefaultHandler는 핸들러가 등록되지 않은 이벤트 타입의 메시지를 역직렬화하지 않고 받습니다.
+// 카탈로그에 없는 이벤트 타입도 받으므로 다른 팀의 이벤트가 섞인 토픽을 그대로 전달하거나 보관할 때 사용합니다.
+// 반환한 오류는 EventHandler와 같이 재시도되고 분류됩니다.
+type DefaultHandler interface {
+	HandleRaw(context.Context, RawMessage) error
+}
+
+// BatchEventHandler는 같은 이벤트 타입의 메시지를 모아 한 번에 처리합니다.
+// 배치는 최대 크기나 최대 대기 시간에 도달하면 전달되며, 한 파티션의 메시지는 받은 순서대로 담깁니다.
+// 일부 메시지만 실패했으면 *BatchError를 반환해 실패한 메시지만 다시 시도하게 할 수 있고,
+// 그 밖의 오류는 배치 전체의 실패로 처리됩니다.
+type BatchEventHandler interface {
+	EventType() string
+	HandleBatch(context.Context, []Message) error
+}
+
+// BatchError는 배치 중 실패한 메시지를 HandleBatch에 전달된 슬라이스의 인덱스로 알립니다.
+type BatchError struct {
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d messages in batch failed", len(e.Errors))
+}
+
+// TopicPartition은 컨슈머 그룹 세션에서 할당받은 파티션입니다.
+type TopicPartition struct {
+	Topic     string
+	Partition int32
+}
+
+// PartitionLifecycle은 파티션별 상태를 가진 핸들러가 선택적으로 구현합니다.
+// OnAssigned는 세션이 시작되어 파티션을 할당받은 뒤 메시지를 처리하기 전에, OnRevoked는 세션이 끝나
+// 파티션을 잃기 전에 호출됩니다. OnRevoked가 호출될 때는 해당 파티션에서 처리 중인 메시지가 모두
+// 끝났으며, OnRevoked가 반환된 뒤 오프셋을 커밋합니다. sarama는 리밸런싱마다 모든 파티션을 회수하고
+// 다시 할당하므로 두 콜백은 세션의 전체 파티션을 받습니다.
+type PartitionLifecycle interface {
+	OnAssigned(ctx context.Context, partitions []TopicPartition) error
+	OnRevoked(ctx context.Context, partitions []TopicPartition) error
+}
@@ -2,14 +2,12 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 
 	"github.com/hoo47/kafka_ex/pkg/events"
-
-	"google.golang.org/protobuf/proto"
 )
 
+// AppUninstallHandler는 events.TypedHandler[*events.AppUninstallEvent]를 구현합니다.
 type AppUninstallHandler struct {
 	logger *slog.Logger
 }
@@ -18,16 +16,7 @@ func NewAppUninstallHandler(logger *slog.Logger) *AppUninstallHandler {
 	return &AppUninstallHandler{logger: logger}
 }
 
-func (h *AppUninstallHandler) EventType() string {
-	return "AppUninstallEvent"
-}
-
-func (h *AppUninstallHandler) Handle(ctx context.Context, msg proto.Message) error {
-	event, ok := msg.(*events.AppUninstallEvent)
-	if !ok {
-		return fmt.Errorf("invalid event type: expected AppUninstallEvent")
-	}
-
+func (h *AppUninstallHandler) Handle(ctx context.Context, event *events.AppUninstallEvent) error {
 	h.logger.Info("handling app uninstall event",
 		"app_id", event.AppId,
 		"channel_id", event.ChannelId,
@@ -0,0 +1,271 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Metadata는 조건식에서 사용할 수 있는 메시지의 Kafka 메타데이터입니다.
+type Metadata struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Timestamp time.Time
+}
+
+// Metadata는 배치 메시지의 Kafka 메타데이터를 반환합니다.
+func (m Message) Metadata() Metadata {
+	return Metadata{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Key:       m.Key,
+		Timestamp: m.Timestamp,
+	}
+}
+
+// Predicate는 역직렬화한 이벤트와 메타데이터를 보고 핸들러에 전달할지 결정합니다.
+type Predicate func(event proto.Message, meta Metadata) bool
+
+// HandlerOption은 RegisterHandler와 RegisterBatchHandler의 선택적인 동작을 설정합니다.
+type HandlerOption func(*handlerOptions)
+
+type handlerOptions struct {
+	predicates []Predicate
+}
+
+// WithPredicate는 p를 만족하는 이벤트만 핸들러에 전달합니다. 여러 번 지정하면 모두 만족해야 하며,
+// 만족하지 않는 이벤트는 핸들러를 호출하지 않고 처리한 것으로 봅니다.
+func WithPredicate(p Predicate) HandlerOption {
+	return func(o *handlerOptions) {
+		o.predicates = append(o.predicates, p)
+	}
+}
+
+func (o handlerOptions) match(event proto.Message, meta Metadata) bool {
+	for _, p := range o.predicates {
+		if !p(event, meta) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParsePredicate는 desc 메시지에 대한 조건식을 Predicate로 변환합니다. 필드 경로는 등록할 때 검사하므로
+// 잘못된 조건식은 메시지를 받기 전에 오류가 됩니다.
+//
+//	<경로> <연산자> <값>
+//
+// 경로는 proto 필드 이름을 점으로 이은 것(app_id, channel.id)이거나 메타데이터(@topic, @key, @partition)입니다.
+// 중간 필드는 반복되지 않는 메시지 필드여야 하고 마지막 필드는 bytes가 아닌 스칼라 또는 enum이어야 합니다.
+// 연산자는 ==, !=, in, not in이며 값은 JSON으로 씁니다. in과 not in은 배열을 받고, enum은 이름이나 번호로 씁니다.
+//
+//	app_id == "app-1"
+//	channel_id in ["c1", "c2"]
+//	@partition != 0
+func ParsePredicate(desc protoreflect.MessageDescriptor, expr string) (Predicate, error) {
+	path, rest, _ := strings.Cut(strings.TrimSpace(expr), " ")
+	rest = strings.TrimSpace(rest)
+
+	var op string
+	for _, candidate := range []string{"==", "!=", "not in", "in"} {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if path == "" || op == "" {
+		return nil, fmt.Errorf("invalid filter %q: want <path> <==|!=|in|not in> <value>", expr)
+	}
+
+	operand, err := resolveOperand(desc, path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+
+	input := strings.NewReader(strings.TrimSpace(strings.TrimPrefix(rest, op)))
+	dec := json.NewDecoder(input)
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid filter %q: value must be JSON: %w", expr, err)
+	}
+	// dec.More는 뒤에 남은 ]나 }를 값의 끝으로 보므로, 디코더가 읽어 둔 나머지와 아직 읽지 않은 입력을
+	// 모두 확인합니다.
+	trailing, err := io.ReadAll(io.MultiReader(dec.Buffered(), input))
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	if len(bytes.TrimSpace(trailing)) > 0 {
+		return nil, fmt.Errorf("invalid filter %q: unexpected text after value", expr)
+	}
+
+	var values []any
+	if op == "in" || op == "not in" {
+		items, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid filter %q: %s needs an array", expr, op)
+		}
+		for _, item := range items {
+			value, err := operand.literal(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+			}
+			values = append(values, value)
+		}
+	} else {
+		value, err := operand.literal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+		}
+		values = []any{value}
+	}
+
+	negate := op == "!=" || op == "not in"
+	return func(event proto.Message, meta Metadata) bool {
+		actual, ok := operand.get(event, meta)
+		if !ok {
+			return false
+		}
+		for _, value := range values {
+			if actual == value {
+				return !negate
+			}
+		}
+		return negate
+	}, nil
+}
+
+// operand는 조건식의 경로가 가리키는 값입니다. 값은 비교할 수 있도록 bool, string, int64, uint64,
+// float64 중 하나로 바꿉니다.
+type operand struct {
+	kind protoreflect.Kind
+	enum protoreflect.EnumDescriptor
+	get  func(event proto.Message, meta Metadata) (any, bool)
+}
+
+func resolveOperand(desc protoreflect.MessageDescriptor, path string) (operand, error) {
+	switch path {
+	case "@topic":
+		return operand{kind: protoreflect.StringKind, get: func(_ proto.Message, meta Metadata) (any, bool) {
+			return meta.Topic, true
+		}}, nil
+	case "@key":
+		return operand{kind: protoreflect.StringKind, get: func(_ proto.Message, meta Metadata) (any, bool) {
+			return string(meta.Key), true
+		}}, nil
+	case "@partition":
+		return operand{kind: protoreflect.Int32Kind, get: func(_ proto.Message, meta Metadata) (any, bool) {
+			return int64(meta.Partition), true
+		}}, nil
+	}
+	if strings.HasPrefix(path, "@") {
+		return operand{}, fmt.Errorf("unknown metadata %s", path)
+	}
+
+	var fields []protoreflect.FieldDescriptor
+	current := desc
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		field := current.Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return operand{}, fmt.Errorf("field %s not found in %s", name, current.FullName())
+		}
+		if field.IsList() || field.IsMap() {
+			return operand{}, fmt.Errorf("field %s is repeated", name)
+		}
+		fields = append(fields, field)
+
+		isMessage := field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind
+		if i < len(names)-1 {
+			if !isMessage {
+				return operand{}, fmt.Errorf("field %s is not a message", name)
+			}
+			current = field.Message()
+		} else if isMessage || field.Kind() == protoreflect.BytesKind {
+			return operand{}, fmt.Errorf("field %s must be a scalar or enum", name)
+		}
+	}
+
+	last := fields[len(fields)-1]
+	return operand{
+		kind: last.Kind(),
+		enum: last.Enum(),
+		get: func(event proto.Message, _ Metadata) (any, bool) {
+			if event == nil {
+				return nil, false
+			}
+			m := event.ProtoReflect()
+			if m.Descriptor().FullName() != desc.FullName() {
+				return nil, false
+			}
+			for _, field := range fields[:len(fields)-1] {
+				m = m.Get(field).Message()
+			}
+			return scalarValue(last.Kind(), m.Get(last)), true
+		},
+	}, nil
+}
+
+func scalarValue(kind protoreflect.Kind, v protoreflect.Value) any {
+	switch kind {
+	case protoreflect.BoolKind:
+		return v.Bool()
+	case protoreflect.StringKind:
+		return v.String()
+	case protoreflect.EnumKind:
+		return int64(v.Enum())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	default:
+		return v.Int()
+	}
+}
+
+// literal은 JSON 값을 경로의 값과 같은 타입으로 바꿉니다.
+func (o operand) literal(raw any) (any, error) {
+	switch o.kind {
+	case protoreflect.BoolKind:
+		if b, ok := raw.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("want a bool, got %v", raw)
+	case protoreflect.StringKind:
+		if s, ok := raw.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("want a string, got %v", raw)
+	case protoreflect.EnumKind:
+		if name, ok := raw.(string); ok {
+			value := o.enum.Values().ByName(protoreflect.Name(name))
+			if value == nil {
+				return nil, fmt.Errorf("enum %s has no value %s", o.enum.FullName(), name)
+			}
+			return int64(value.Number()), nil
+		}
+	}
+
+	n, ok := raw.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("want a number, got %v", raw)
+	}
+	switch o.kind {
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.ParseUint(n.String(), 10, 64)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return n.Float64()
+	default:
+		return n.Int64()
+	}
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	pkgevents "github.com/hoo47/kafka_ex/pkg/events"
+)
+
+func TestParsePredicate(t *testing.T) {
+	event := &pkgevents.AppInstallEvent{AppId: "app-1", ChannelId: "c2"}
+	meta := Metadata{Topic: "app.events", Partition: 3, Key: []byte("app-1")}
+	desc := event.ProtoReflect().Descriptor()
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`app_id == "app-1"`, true},
+		{`app_id == "app-2"`, false},
+		{`app_id != "app-2"`, true},
+		{`channel_id in ["c1", "c2"]`, true},
+		{`channel_id not in ["c1", "c2"]`, false},
+		{`@topic == "app.events"`, true},
+		{`@key == "app-1"`, true},
+		{`@partition in [0, 1]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := ParsePredicate(desc, tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p(event, meta))
+		})
+	}
+}
+
+func TestParsePredicate_NestedAndEnumFields(t *testing.T) {
+	field := &descriptorpb.FieldDescriptorProto{
+		Number:  proto.Int32(3),
+		Label:   descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Options: &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)},
+	}
+	desc := field.ProtoReflect().Descriptor()
+
+	for expr, want := range map[string]bool{
+		`options.deprecated == true`: true,
+		`label == "LABEL_REPEATED"`:  true,
+		`label == 1`:                 false,
+		`number in [1, 2, 3]`:        true,
+	} {
+		p, err := ParsePredicate(desc, expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, p(field, Metadata{}), expr)
+	}
+}
+
+func TestParsePredicate_Errors(t *testing.T) {
+	desc := (&pkgevents.AppInstallEvent{}).ProtoReflect().Descriptor()
+
+	for _, expr := range []string{
+		`app_id`,
+		`app_id ~ "x"`,
+		`unknown == "x"`,
+		`app_id.id == "x"`,
+		`app_id == 1`,
+		`app_id in "x"`,
+		`app_id == "x" extra`,
+		`app_id == "x" ]`,
+		`app_id == "x" }`,
+		`app_id in ["x"]]`,
+		`@offset == 1`,
+	} {
+		_, err := ParsePredicate(desc, expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestEventRouter_HandleBatchFiltersAndRemapsErrors(t *testing.T) {
+	router := NewEventRouter(discardLogger())
+	h := &recordingBatchHandler{fail: map[int]bool{1: true}}
+	p, err := ParsePredicate((&pkgevents.AppInstallEvent{}).ProtoReflect().Descriptor(), `channel_id == "c1"`)
+	require.NoError(t, err)
+	router.RegisterBatchHandler(h, WithPredicate(p))
+
+	msgs := []Message{
+		{Event: &pkgevents.AppInstallEvent{ChannelId: "c1"}, Offset: 10},
+		{Event: &pkgevents.AppInstallEvent{ChannelId: "c2"}, Offset: 11},
+		{Event: &pkgevents.AppInstallEvent{ChannelId: "c1"}, Offset: 12},
+	}
+	err = router.HandleBatch(context.Background(), "AppInstallEvent", msgs)
+
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, []int64{10, 12}, h.offsets)
+	assert.Contains(t, batchErr.Errors, 2)
+	assert.Len(t, batchErr.Errors, 1)
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// recordingBatchHandler는 받은 오프셋을 기록하고 fail에 있는 인덱스의 메시지를 실패시킵니다.
+type recordingBatchHandler struct {
+	fail    map[int]bool
+	offsets []int64
+}
+
+func (h *recordingBatchHandler) EventType() string { return "AppInstallEvent" }
+
+func (h *recordingBatchHandler) HandleBatch(_ context.Context, msgs []Message) error {
+	errs := make(map[int]error)
+	for i, msg := range msgs {
+		h.offsets = append(h.offsets, msg.Offset)
+		if h.fail[i] {
+			errs[i] = errors.New("failed")
+		}
+	}
+	if len(errs) > 0 {
+		return &BatchError{Errors: errs}
+	}
+	return nil
+}
@@ -2,46 +2,205 @@ package events
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"sort"
 
 	"google.golang.org/protobuf/proto"
 )
 
 type EventRouter struct {
-	handlers map[string]struct {
-		handler   EventHandler
-		prototype proto.Message
-	}
-	logger *slog.Logger
+	handlers       map[string]handlerRegistration
+	batchHandlers  map[string]batchRegistration
+	defaultHandler DefaultHandler
+	logger         *slog.Logger
+}
+
+type handlerRegistration struct {
+	handler   EventHandler
+	prototype proto.Message
+	options   handlerOptions
+}
+
+type batchRegistration struct {
+	handler BatchEventHandler
+	options handlerOptions
 }
 
 func NewEventRouter(logger *slog.Logger) *EventRouter {
 	return &EventRouter{
-		handlers: make(map[string]struct {
-			handler   EventHandler
-			prototype proto.Message
-		}),
-		logger: logger,
+		handlers:      make(map[string]handlerRegistration),
+		batchHandlers: make(map[string]batchRegistration),
+		logger:        logger,
+	}
+}
+
+func (r *EventRouter) RegisterHandler(h EventHandler, prototype proto.Message, opts ...HandlerOption) {
+	registration := handlerRegistration{handler: h, prototype: prototype}
+	for _, opt := range opts {
+		opt(&registration.options)
+	}
+	r.handlers[h.EventType()] = registration
+}
+
+// RegisterBatchHandler는 배치 핸들러를 등록합니다. 같은 이벤트 타입에 EventHandler도 등록되어
+// 있으면 배치 핸들러를 사용합니다.
+func (r *EventRouter) RegisterBatchHandler(h BatchEventHandler, opts ...HandlerOption) {
+	registration := batchRegistration{handler: h}
+	for _, opt := range opts {
+		opt(&registration.options)
+	}
+	r.batchHandlers[h.EventType()] = registration
+}
+
+// SetDefaultHandler는 핸들러가 등록되지 않은 이벤트 타입의 메시지를 받을 기본 핸들러를 지정합니다.
+func (r *EventRouter) SetDefaultHandler(h DefaultHandler) {
+	r.defaultHandler = h
+}
+
+// HasDefaultHandler는 기본 핸들러가 지정되어 있는지 반환합니다.
+func (r *EventRouter) HasDefaultHandler() bool {
+	return r.defaultHandler != nil
+}
+
+// HasHandler는 eventType에 핸들러나 배치 핸들러가 등록되어 있는지 반환합니다. 기본 핸들러는 포함하지 않습니다.
+func (r *EventRouter) HasHandler(eventType string) bool {
+	_, ok := r.handlers[eventType]
+	return ok || r.HasBatchHandler(eventType)
+}
+
+// HasBatchHandler는 eventType에 배치 핸들러가 등록되어 있는지 반환합니다.
+func (r *EventRouter) HasBatchHandler(eventType string) bool {
+	_, ok := r.batchHandlers[eventType]
+	return ok
+}
+
+// PartitionLifecycles는 등록된 핸들러 중 PartitionLifecycle을 구현한 핸들러를 이벤트 타입 순서로 반환하며,
+// 기본 핸들러는 마지막에 포함됩니다. 여러 이벤트 타입에 등록된 핸들러는 한 번만 포함됩니다.
+func (r *EventRouter) PartitionLifecycles() []PartitionLifecycle {
+	byType := make(map[string]any, len(r.handlers)+len(r.batchHandlers))
+	for eventType, registration := range r.handlers {
+		byType[eventType] = unwrapHandler(registration.handler)
+	}
+	for eventType, registration := range r.batchHandlers {
+		byType[eventType] = registration.handler
+	}
+
+	eventTypes := make([]string, 0, len(byType))
+	for eventType := range byType {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+
+	var lifecycles []PartitionLifecycle
+	for _, eventType := range eventTypes {
+		lifecycle, ok := byType[eventType].(PartitionLifecycle)
+		if ok && !containsHandler(lifecycles, lifecycle) {
+			lifecycles = append(lifecycles, lifecycle)
+		}
+	}
+	if lifecycle, ok := r.defaultHandler.(PartitionLifecycle); ok && !containsHandler(lifecycles, lifecycle) {
+		lifecycles = append(lifecycles, lifecycle)
 	}
+	return lifecycles
 }
 
-func (r *EventRouter) RegisterHandler(h EventHandler, prototype proto.Message) {
-	r.handlers[h.EventType()] = struct {
-		handler   EventHandler
-		prototype proto.Message
-	}{h, prototype}
+// unwrapHandler는 Typed로 감싼 핸들러이면 감싼 핸들러를 반환합니다.
+func unwrapHandler(h any) any {
+	if wrapper, ok := h.(interface{ Unwrap() any }); ok {
+		return wrapper.Unwrap()
+	}
+	return h
+}
+
+func containsHandler(lifecycles []PartitionLifecycle, lifecycle PartitionLifecycle) bool {
+	if !reflect.TypeOf(lifecycle).Comparable() {
+		return false
+	}
+	for _, l := range lifecycles {
+		if reflect.TypeOf(l).Comparable() && l == lifecycle {
+			return true
+		}
+	}
+	return false
 }
 
-func (r *EventRouter) HandleMessage(ctx context.Context, eventType string, msg proto.Message) error {
+// HandleMessage는 eventType의 핸들러로 이벤트를 처리합니다. 등록할 때 지정한 조건을 만족하지 않는
+// 이벤트는 핸들러를 호출하지 않고 nil을 반환합니다.
+func (r *EventRouter) HandleMessage(ctx context.Context, eventType string, msg proto.Message, meta Metadata) error {
 	registration, exists := r.handlers[eventType]
 	if !exists {
-		return fmt.Errorf("no handler registered for event type: %s", eventType)
+		return fmt.Errorf("%w for event type: %s", ErrNoHandler, eventType)
+	}
+	if !registration.options.match(msg, meta) {
+		r.logger.Debug("event filtered out",
+			"type", eventType,
+			"topic", meta.Topic,
+			"partition", meta.Partition,
+			"offset", meta.Offset)
+		return nil
 	}
 
 	r.logger.Info("handling event",
 		"type", eventType,
-		"handler", fmt.Sprintf("%T", registration.handler))
+		"handler", fmt.Sprintf("%T", unwrapHandler(registration.handler)))
 
 	return registration.handler.Handle(ctx, msg)
 }
+
+// HandleRaw는 기본 핸들러로 메시지를 처리합니다.
+func (r *EventRouter) HandleRaw(ctx context.Context, msg RawMessage) error {
+	if r.defaultHandler == nil {
+		return fmt.Errorf("%w for event type: %s", ErrNoHandler, msg.Type)
+	}
+
+	r.logger.Info("handling raw event",
+		"type", msg.Type,
+		"handler", fmt.Sprintf("%T", r.defaultHandler))
+
+	return r.defaultHandler.HandleRaw(ctx, msg)
+}
+
+// HandleBatch는 eventType의 배치 핸들러로 배치를 처리합니다. 등록할 때 지정한 조건을 만족하는 메시지만
+// 전달하며, 핸들러가 반환한 *BatchError의 인덱스는 msgs의 인덱스로 바꿔 반환합니다.
+func (r *EventRouter) HandleBatch(ctx context.Context, eventType string, msgs []Message) error {
+	registration, exists := r.batchHandlers[eventType]
+	if !exists {
+		return fmt.Errorf("no batch handler registered for event type: %s", eventType)
+	}
+
+	matched := msgs
+	var indexes []int
+	if len(registration.options.predicates) > 0 {
+		matched = nil
+		for i, msg := range msgs {
+			if registration.options.match(msg.Event, msg.Metadata()) {
+				matched = append(matched, msg)
+				indexes = append(indexes, i)
+			}
+		}
+		if len(matched) == 0 {
+			return nil
+		}
+	}
+
+	r.logger.Info("handling event batch",
+		"type", eventType,
+		"size", len(matched),
+		"handler", fmt.Sprintf("%T", registration.handler))
+
+	err := registration.handler.HandleBatch(ctx, matched)
+	var batchErr *BatchError
+	if indexes == nil || !errors.As(err, &batchErr) {
+		return err
+	}
+	remapped := make(map[int]error, len(batchErr.Errors))
+	for i, itemErr := range batchErr.Errors {
+		if i >= 0 && i < len(indexes) {
+			remapped[indexes[i]] = itemErr
+		}
+	}
+	return &BatchError{Errors: remapped}
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TypedHandler는 T 타입의 이벤트를 타입 단언 없이 받는 핸들러입니다. Typed나 Register로 등록하면
+// 이벤트 타입 이름과 prototype을 T의 proto 메시지에서 가져오므로 둘이 어긋날 수 없습니다.
+// T는 생성된 메시지의 포인터 타입이어야 합니다.
+type TypedHandler[T proto.Message] interface {
+	Handle(ctx context.Context, event T) error
+}
+
+// Typed는 TypedHandler를 EventHandler로 감쌉니다. 이벤트 타입 이름은 T의 proto 메시지 이름
+// (events.AppInstallEvent이면 AppInstallEvent)입니다. T가 proto.Message 같은 인터페이스이거나
+// dynamicpb.Message처럼 타입만으로 설명자를 알 수 없으면 Err가 오류를 반환하며, bootstrap.Build는
+// 이런 핸들러를 등록하지 않고 실패합니다.
+//
+//	events.Typed[*pkgevents.AppInstallEvent](handler)
+func Typed[T proto.Message](h TypedHandler[T]) EventHandler {
+	return newTypedHandler(h)
+}
+
+// Register는 TypedHandler를 T의 prototype과 함께 라우터에 등록합니다. T에서 설명자를 알 수 없으면
+// 등록하지 않고 오류를 반환합니다.
+func Register[T proto.Message](r *EventRouter, h TypedHandler[T], opts ...HandlerOption) error {
+	typed := newTypedHandler(h)
+	if typed.err != nil {
+		return typed.err
+	}
+	r.RegisterHandler(typed, typed.Prototype(), opts...)
+	return nil
+}
+
+type typedHandler[T proto.Message] struct {
+	handler     TypedHandler[T]
+	messageType protoreflect.MessageType
+	err         error
+}
+
+func newTypedHandler[T proto.Message](h TypedHandler[T]) *typedHandler[T] {
+	messageType, err := typedMessageType[T]()
+	return &typedHandler[T]{handler: h, messageType: messageType, err: err}
+}
+
+// typedMessageType은 T의 메시지 타입을 반환합니다. 생성된 메시지 타입은 nil 포인터로도 설명자를
+// 돌려주지만, 인터페이스 타입의 nil 값이나 dynamicpb.Message의 nil 포인터는 패닉하므로 오류로 바꿉니다.
+func typedMessageType[T proto.Message]() (messageType protoreflect.MessageType, err error) {
+	invalid := fmt.Errorf("typed handler: %s is not a generated message type", reflect.TypeOf((*T)(nil)).Elem())
+	var zero T
+	if any(zero) == nil {
+		return nil, invalid
+	}
+	defer func() {
+		if recover() != nil {
+			messageType, err = nil, invalid
+		}
+	}()
+	messageType = zero.ProtoReflect().Type()
+	if messageType.Descriptor() == nil {
+		return nil, invalid
+	}
+	return messageType, nil
+}
+
+// Err는 T에서 메시지 타입을 알 수 없어 핸들러를 등록할 수 없을 때 오류를 반환합니다.
+func (h *typedHandler[T]) Err() error {
+	return h.err
+}
+
+// EventType은 T의 proto 메시지 이름을 반환합니다. Err가 오류를 반환하면 빈 문자열입니다.
+func (h *typedHandler[T]) EventType() string {
+	if h.err != nil {
+		return ""
+	}
+	return string(h.messageType.Descriptor().Name())
+}
+
+// Prototype은 T의 빈 메시지를 반환합니다. Err가 오류를 반환하면 nil입니다.
+func (h *typedHandler[T]) Prototype() proto.Message {
+	if h.err != nil {
+		return nil
+	}
+	return h.messageType.New().Interface()
+}
+
+func (h *typedHandler[T]) Handle(ctx context.Context, msg proto.Message) error {
+	if h.err != nil {
+		return Permanent(h.err)
+	}
+	event, ok := msg.(T)
+	if !ok {
+		return Permanent(fmt.Errorf("invalid event type: expected %T, got %T", event, msg))
+	}
+	return h.handler.Handle(ctx, event)
+}
+
+// Unwrap은 감싼 핸들러를 반환합니다. PartitionLifecycle처럼 핸들러가 선택적으로 구현하는 인터페이스를
+// 확인할 때 사용합니다.
+func (h *typedHandler[T]) Unwrap() any {
+	return h.handler
+}
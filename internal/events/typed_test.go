@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	pkgevents "github.com/hoo47/kafka_ex/pkg/events"
+)
+
+// installHandler는 TypedHandler[*pkgevents.AppInstallEvent]이며 PartitionLifecycle도 구현합니다.
+type installHandler struct {
+	received []*pkgevents.AppInstallEvent
+}
+
+func (h *installHandler) Handle(_ context.Context, event *pkgevents.AppInstallEvent) error {
+	h.received = append(h.received, event)
+	return nil
+}
+
+func (h *installHandler) OnAssigned(context.Context, []TopicPartition) error { return nil }
+
+func (h *installHandler) OnRevoked(context.Context, []TopicPartition) error { return nil }
+
+func TestTyped_DerivesEventTypeAndPrototype(t *testing.T) {
+	h := Typed[*pkgevents.AppInstallEvent](&installHandler{})
+
+	assert.Equal(t, "AppInstallEvent", h.EventType())
+	prototype := h.(interface{ Prototype() proto.Message }).Prototype()
+	assert.IsType(t, &pkgevents.AppInstallEvent{}, prototype)
+	assert.NotNil(t, prototype)
+}
+
+func TestRegister_RoutesTypedEvents(t *testing.T) {
+	router := NewEventRouter(discardLogger())
+	h := &installHandler{}
+	require.NoError(t, Register[*pkgevents.AppInstallEvent](router, h))
+
+	event := &pkgevents.AppInstallEvent{AppId: "app-1"}
+	require.NoError(t, router.HandleMessage(context.Background(), "AppInstallEvent", event, Metadata{}))
+	require.Len(t, h.received, 1)
+	assert.Same(t, event, h.received[0])
+
+	// 다른 타입의 메시지는 재시도하지 않을 실패입니다.
+	err := router.HandleMessage(context.Background(), "AppInstallEvent", &pkgevents.AppUninstallEvent{}, Metadata{})
+	assert.True(t, IsPermanent(err))
+
+	// 감싼 핸들러가 구현한 인터페이스도 찾습니다.
+	assert.Equal(t, []PartitionLifecycle{h}, router.PartitionLifecycles())
+}
+
+type anyMessageHandler struct{}
+
+func (anyMessageHandler) Handle(context.Context, proto.Message) error { return nil }
+
+type dynamicHandler struct{}
+
+func (dynamicHandler) Handle(context.Context, *dynamicpb.Message) error { return nil }
+
+func TestTyped_RejectsTypesWithoutDescriptor(t *testing.T) {
+	// 인터페이스나 dynamicpb.Message는 타입만으로 메시지를 알 수 없으므로 패닉 대신 오류를 반환합니다.
+	for _, h := range []EventHandler{
+		Typed[proto.Message](anyMessageHandler{}),
+		Typed[*dynamicpb.Message](dynamicHandler{}),
+	} {
+		err := h.(interface{ Err() error }).Err()
+		assert.ErrorContains(t, err, "is not a generated message type")
+		assert.Empty(t, h.EventType())
+		assert.True(t, IsPermanent(h.Handle(context.Background(), &pkgevents.AppInstallEvent{})))
+	}
+
+	router := NewEventRouter(discardLogger())
+	assert.Error(t, Register[proto.Message](router, anyMessageHandler{}))
+	assert.False(t, router.HasHandler(""))
+}
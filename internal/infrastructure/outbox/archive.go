@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Archiver는 보존 기간이 지난 레코드를 삭제하기 전에 보관합니다.
+// 보관 후 삭제가 실패하면 같은 레코드가 다시 전달될 수 있으므로 구현은 중복에 안전해야 합니다.
+type Archiver interface {
+	Archive(ctx context.Context, records []Record) error
+}
+
+// TableArchiver는 레코드를 같은 데이터베이스의 event_archive 테이블로 복사합니다.
+// 이미 보관된 레코드는 건너뜁니다.
+type TableArchiver struct {
+	store *SQLStore
+}
+
+func NewTableArchiver(store *SQLStore) *TableArchiver {
+	return &TableArchiver{store: store}
+}
+
+func (a *TableArchiver) Archive(ctx context.Context, records []Record) error {
+	tx, err := a.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := a.store.dialect.rebind(`
+        INSERT INTO event_archive (id, aggregate_type, aggregate_id, type, payload, idempotency_key, created_at, attempts)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        ` + a.store.dialect.ignoreConflict("id"))
+
+	for _, rec := range records {
+		if _, err := tx.ExecContext(ctx, query,
+			rec.ID,
+			rec.AggregateType,
+			rec.AggregateID,
+			rec.Type,
+			rec.Payload,
+			rec.IdempotencyKey,
+			rec.CreatedAt.UTC(),
+			rec.Attempts,
+		); err != nil {
+			return fmt.Errorf("failed to insert archived event: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// JSONLArchiver는 Archive 호출마다 gzip으로 압축된 JSONL 파일을 하나씩 dir에 씁니다.
+// 파일은 임시 이름으로 쓴 뒤 이름을 바꾸므로 완성되지 않은 파일이 남지 않습니다.
+type JSONLArchiver struct {
+	dir string
+	now func() time.Time
+}
+
+func NewJSONLArchiver(dir string) *JSONLArchiver {
+	return &JSONLArchiver{
+		dir: dir,
+		now: time.Now,
+	}
+}
+
+// ArchivedRecord는 JSONL 보관 파일의 한 줄입니다. Payload는 base64로 인코딩됩니다.
+type ArchivedRecord struct {
+	ID             string    `json:"id"`
+	AggregateType  string    `json:"aggregate_type"`
+	AggregateID    string    `json:"aggregate_id"`
+	Type           string    `json:"type"`
+	Payload        []byte    `json:"payload"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	CreatedAt      time.Time `json:"created_at"`
+	Attempts       int       `json:"attempts"`
+}
+
+func (a *JSONLArchiver) Archive(ctx context.Context, records []Record) error {
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	name := fmt.Sprintf("event_archive-%s.jsonl.gz", a.now().UTC().Format("20060102T150405.000000000"))
+	tmp, err := os.CreateTemp(a.dir, name+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	enc := json.NewEncoder(gz)
+	for _, rec := range records {
+		if err := enc.Encode(ArchivedRecord{
+			ID:             rec.ID,
+			AggregateType:  rec.AggregateType,
+			AggregateID:    rec.AggregateID,
+			Type:           rec.Type,
+			Payload:        rec.Payload,
+			IdempotencyKey: rec.IdempotencyKey,
+			CreatedAt:      rec.CreatedAt,
+			Attempts:       rec.Attempts,
+		}); err != nil {
+			return fmt.Errorf("failed to write archived event: %w", err)
+		}
+	}
+
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress archive file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close archive file: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(a.dir, name))
+}
@@ -0,0 +1,73 @@
+package outbox_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/infrastructure/outbox"
+)
+
+func archivedRecords() []outbox.Record {
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []outbox.Record{
+		{ID: "id-1", AggregateType: "app", AggregateID: "app1", Type: "AppInstallEvent", Payload: []byte{0x0, 0x1}, IdempotencyKey: "k1", CreatedAt: createdAt},
+		{ID: "id-2", AggregateType: "app", AggregateID: "app2", Type: "AppUninstallEvent", Payload: []byte{0x0, 0x2}, IdempotencyKey: "k2", CreatedAt: createdAt, Attempts: 2},
+	}
+}
+
+func TestTableArchiver_Archive(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	archiver := outbox.NewTableArchiver(outbox.NewSQLiteStore(db))
+
+	require.NoError(t, archiver.Archive(ctx, archivedRecords()))
+	// 이미 보관된 레코드를 다시 보관해도 중복되지 않습니다.
+	require.NoError(t, archiver.Archive(ctx, archivedRecords()))
+
+	var count, attempts int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM event_archive`).Scan(&count))
+	require.NoError(t, db.QueryRow(`SELECT attempts FROM event_archive WHERE id = 'id-2'`).Scan(&attempts))
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestJSONLArchiver_Archive(t *testing.T) {
+	dir := t.TempDir()
+	archiver := outbox.NewJSONLArchiver(dir)
+
+	require.NoError(t, archiver.Archive(context.Background(), archivedRecords()))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Regexp(t, `event_archive-\d{8}T\d{6}\.\d{9}\.jsonl\.gz$`, files[0])
+
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	var lines []outbox.ArchivedRecord
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var rec outbox.ArchivedRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		lines = append(lines, rec)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, lines, 2)
+	assert.Equal(t, "id-1", lines[0].ID)
+	assert.Equal(t, []byte{0x0, 0x2}, lines[1].Payload)
+	assert.Equal(t, 2, lines[1].Attempts)
+}
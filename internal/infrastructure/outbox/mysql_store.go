@@ -0,0 +1,17 @@
+package outbox
+
+import "database/sql"
+
+// MySQL에는 ON CONFLICT DO NOTHING이 없으므로 아무것도 바꾸지 않는 갱신으로 대신합니다.
+// 이 경우 RowsAffected가 0이 되어 중복으로 판별됩니다.
+var mysqlDialect = dialect{
+	name:           "mysql",
+	conflictIgnore: "ON DUPLICATE KEY UPDATE %[1]s = %[1]s",
+	lockClause:     "FOR UPDATE SKIP LOCKED",
+}
+
+// NewMySQLStore는 MySQL 8 이상용 OutboxStore를 생성합니다.
+// DSN에 parseTime=true가 설정되어 있어야 합니다.
+func NewMySQLStore(db *sql.DB) *SQLStore {
+	return newSQLStore(db, mysqlDialect)
+}
@@ -0,0 +1,412 @@
+// Package outboxtest는 모든 OutboxStore 구현이 만족해야 하는 공통 동작을 검증합니다.
+package outboxtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoo47/kafka_ex/internal/infrastructure/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewStoreFunc은 비어 있는 outbox 테이블에 연결된 저장소를 생성합니다.
+// 각 테스트 케이스마다 호출되므로 케이스 사이에 상태가 공유되지 않아야 합니다.
+type NewStoreFunc func(t *testing.T) outbox.OutboxStore
+
+// RunStoreTests는 OutboxStore 적합성 테스트를 실행합니다.
+func RunStoreTests(t *testing.T, newStore NewStoreFunc) {
+	t.Run("InsertReportsDuplicates", func(t *testing.T) {
+		testInsertReportsDuplicates(t, newStore(t))
+	})
+	t.Run("ClaimBatchInCreationOrder", func(t *testing.T) {
+		testClaimBatchInCreationOrder(t, newStore(t))
+	})
+	t.Run("MarkPublished", func(t *testing.T) {
+		testMarkPublished(t, newStore(t))
+	})
+	t.Run("MarkFailedBacksOff", func(t *testing.T) {
+		testMarkFailedBacksOff(t, newStore(t))
+	})
+	t.Run("QuarantineAndRequeue", func(t *testing.T) {
+		testQuarantineAndRequeue(t, newStore(t))
+	})
+	t.Run("ExpiredClaimIsReclaimed", func(t *testing.T) {
+		testExpiredClaimIsReclaimed(t, newStore(t))
+	})
+	t.Run("ScheduledAndCancel", func(t *testing.T) {
+		testScheduledAndCancel(t, newStore(t))
+	})
+	t.Run("PurgePublished", func(t *testing.T) {
+		store := newStore(t)
+		retention, ok := store.(outbox.RetentionStore)
+		if !ok {
+			t.Skip("store does not implement RetentionStore")
+		}
+		testPurgePublished(t, store, retention)
+	})
+	t.Run("BatchPublishedAndReleased", func(t *testing.T) {
+		store := newStore(t)
+		batches, ok := store.(outbox.BatchStore)
+		if !ok {
+			t.Skip("store does not implement BatchStore")
+		}
+		testBatchPublishedAndReleased(t, store, batches)
+	})
+}
+
+// ApplyMigrations는 drop 구문으로 기존 객체를 지운 뒤 dir의 Flyway 형식(V<버전>__<설명>.sql)
+// 마이그레이션을 버전 순서대로 실행합니다. 테스트 전용 데이터베이스에서만 사용해야 합니다.
+func ApplyMigrations(t *testing.T, db *sql.DB, dir string, drop ...string) {
+	t.Helper()
+
+	for _, stmt := range drop {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "V*__*.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files, "no migrations found in %s", dir)
+
+	sort.Slice(files, func(i, j int) bool {
+		return migrationVersion(t, files[i]) < migrationVersion(t, files[j])
+	})
+
+	for _, file := range files {
+		script, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		_, err = db.Exec(string(script))
+		require.NoError(t, err, "failed to apply migration %s", filepath.Base(file))
+	}
+}
+
+func migrationVersion(t *testing.T, file string) int {
+	name := strings.TrimPrefix(filepath.Base(file), "V")
+	version, err := strconv.Atoi(name[:strings.Index(name, "__")])
+	require.NoError(t, err, "invalid migration file name %s", file)
+	return version
+}
+
+func newRecord(key string, createdAt time.Time) outbox.Record {
+	id := uuid.NewString()
+	if key == "" {
+		key = id
+	}
+	return outbox.Record{
+		ID:             id,
+		AggregateType:  "app",
+		AggregateID:    "app123",
+		Type:           "AppInstallEvent",
+		Payload:        []byte{0x0, 0x0, 0x0, 0x0, 0x1, 0xa, 0x3},
+		IdempotencyKey: key,
+		CreatedAt:      createdAt,
+	}
+}
+
+func ids(records []outbox.Record) []string {
+	result := make([]string, len(records))
+	for i, rec := range records {
+		result[i] = rec.ID
+	}
+	return result
+}
+
+func testInsertReportsDuplicates(t *testing.T, store outbox.OutboxStore) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	inserted, err := store.Insert(ctx, []outbox.Record{newRecord("key-1", now)})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, inserted)
+
+	// 같은 키의 재시도와, 한 배치 안에서 반복된 키는 모두 중복으로 보고됩니다.
+	inserted, err = store.Insert(ctx, []outbox.Record{
+		newRecord("key-1", now),
+		newRecord("key-2", now),
+		newRecord("key-2", now),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true, false}, inserted)
+
+	claimed, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 2)
+}
+
+func testClaimBatchInCreationOrder(t *testing.T, store outbox.OutboxStore) {
+	ctx := context.Background()
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+
+	// 생성 순서와 삽입 순서가 다르도록 역순으로 저장합니다.
+	records := []outbox.Record{
+		newRecord("", base.Add(2*time.Second)),
+		newRecord("", base.Add(1*time.Second)),
+		newRecord("", base),
+	}
+	_, err := store.Insert(ctx, records)
+	require.NoError(t, err)
+
+	first, err := store.ClaimBatch(ctx, 2, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{records[2].ID, records[1].ID}, ids(first))
+	assert.Equal(t, records[2].Payload, first[0].Payload)
+	assert.Equal(t, records[2].IdempotencyKey, first[0].IdempotencyKey)
+	assert.True(t, records[2].CreatedAt.Equal(first[0].CreatedAt))
+
+	// 선점된 레코드는 다시 반환되지 않습니다.
+	second, err := store.ClaimBatch(ctx, 2, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{records[0].ID}, ids(second))
+
+	third, err := store.ClaimBatch(ctx, 2, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, third)
+}
+
+func testMarkPublished(t *testing.T, store outbox.OutboxStore) {
+	ctx := context.Background()
+	now := time.Now().UTC().Add(-time.Minute)
+
+	_, err := store.Insert(ctx, []outbox.Record{newRecord("", now), newRecord("", now.Add(time.Second))})
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimBatch(ctx, 10, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+
+	require.NoError(t, store.MarkPublished(ctx, []string{claimed[0].ID}))
+	require.NoError(t, store.MarkPublished(ctx, nil))
+
+	// lease가 끝난 뒤에는 발행되지 않은 레코드만 다시 선점됩니다.
+	time.Sleep(50 * time.Millisecond)
+	reclaimed, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{claimed[1].ID}, ids(reclaimed))
+}
+
+func testMarkFailedBacksOff(t *testing.T, store outbox.OutboxStore) {
+	ctx := context.Background()
+
+	rec := newRecord("", time.Now().UTC())
+	_, err := store.Insert(ctx, []outbox.Record{rec})
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, outbox.StatusInFlight, claimed[0].Status)
+	assert.Equal(t, 0, claimed[0].Attempts)
+
+	require.NoError(t, store.MarkFailed(ctx, rec.ID, errors.New("broker unavailable"), time.Now().Add(50*time.Millisecond)))
+
+	// 재시도 시각 전에는 선점되지 않습니다.
+	none, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	time.Sleep(100 * time.Millisecond)
+
+	reclaimed, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, reclaimed, 1)
+	assert.Equal(t, rec.ID, reclaimed[0].ID)
+	assert.Equal(t, 1, reclaimed[0].Attempts)
+	assert.Equal(t, "broker unavailable", reclaimed[0].LastError)
+}
+
+func testQuarantineAndRequeue(t *testing.T, store outbox.OutboxStore) {
+	ctx := context.Background()
+	now := time.Now().UTC().Add(-time.Minute)
+
+	poison := newRecord("", now)
+	healthy := newRecord("", now.Add(time.Second))
+	_, err := store.Insert(ctx, []outbox.Record{poison, healthy})
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimBatch(ctx, 1, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []string{poison.ID}, ids(claimed))
+
+	require.NoError(t, store.Quarantine(ctx, poison.ID, errors.New("message too large")))
+
+	// 격리된 레코드는 뒤의 레코드를 막지 않습니다.
+	claimed, err = store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{healthy.ID}, ids(claimed))
+
+	quarantined, err := store.ListQuarantined(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, quarantined, 1)
+	assert.Equal(t, poison.ID, quarantined[0].ID)
+	assert.Equal(t, outbox.StatusQuarantined, quarantined[0].Status)
+	assert.Equal(t, 1, quarantined[0].Attempts)
+	assert.Equal(t, "message too large", quarantined[0].LastError)
+
+	// 격리되지 않은 레코드는 Requeue 대상이 아닙니다.
+	n, err := store.Requeue(ctx, []string{healthy.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = store.Requeue(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	requeued, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []string{poison.ID}, ids(requeued))
+	assert.Equal(t, 0, requeued[0].Attempts)
+}
+
+func testExpiredClaimIsReclaimed(t *testing.T, store outbox.OutboxStore) {
+	ctx := context.Background()
+
+	rec := newRecord("", time.Now().UTC())
+	_, err := store.Insert(ctx, []outbox.Record{rec})
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimBatch(ctx, 10, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	time.Sleep(50 * time.Millisecond)
+
+	reclaimed, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{rec.ID}, ids(reclaimed))
+}
+
+func testScheduledAndCancel(t *testing.T, store outbox.OutboxStore) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	immediate := newRecord("", now.Add(-time.Minute))
+	due := newRecord("", now.Add(-time.Minute+time.Second))
+	due.DeliverAt = now.Add(-time.Second)
+	later := newRecord("", now.Add(-time.Minute+2*time.Second))
+	later.DeliverAt = now.Add(time.Hour)
+	cancelled := newRecord("", now.Add(-time.Minute+3*time.Second))
+	cancelled.DeliverAt = now.Add(time.Hour)
+	_, err := store.Insert(ctx, []outbox.Record{immediate, due, later, cancelled})
+	require.NoError(t, err)
+
+	// 예약 이벤트만 취소할 수 있으며, 취소된 이벤트는 다시 취소되지 않습니다.
+	ok, err := store.Cancel(ctx, cancelled.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.Cancel(ctx, cancelled.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = store.Cancel(ctx, immediate.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// 발행 시각이 되지 않은 예약 이벤트는 선점되지 않습니다.
+	if scheduled, ok := store.(outbox.ScheduledStore); ok {
+		claimed, err := scheduled.ClaimScheduled(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Equal(t, []string{due.ID}, ids(claimed))
+		assert.True(t, due.DeliverAt.Equal(claimed[0].DeliverAt))
+	}
+
+	claimed, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.NotContains(t, ids(claimed), later.ID)
+	assert.NotContains(t, ids(claimed), cancelled.ID)
+	assert.Contains(t, ids(claimed), immediate.ID)
+
+	// 선점 중인 예약 이벤트는 취소할 수 없습니다.
+	ok, err = store.Cancel(ctx, due.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+type recordingArchiver struct {
+	records []outbox.Record
+}
+
+func (a *recordingArchiver) Archive(_ context.Context, records []outbox.Record) error {
+	a.records = append(a.records, records...)
+	return nil
+}
+
+func testPurgePublished(t *testing.T, store outbox.OutboxStore, retention outbox.RetentionStore) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	old := newRecord("old", now.Add(-48*time.Hour))
+	oldPending := newRecord("old-pending", now.Add(-47*time.Hour))
+	recent := newRecord("recent", now.Add(-time.Hour))
+	_, err := store.Insert(ctx, []outbox.Record{old, oldPending, recent})
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 3)
+	require.NoError(t, store.MarkPublished(ctx, []string{old.ID, recent.ID}))
+	require.NoError(t, store.MarkFailed(ctx, oldPending.ID, errors.New("broker unavailable"), now))
+
+	// 보존 기간이 지났더라도 발행되지 않은 레코드는 삭제되지 않습니다.
+	archiver := &recordingArchiver{}
+	n, err := retention.PurgePublished(ctx, now.Add(-24*time.Hour), 10, archiver)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{old.ID}, ids(archiver.records))
+	assert.Equal(t, old.Payload, archiver.records[0].Payload)
+
+	n, err = retention.PurgePublished(ctx, now.Add(-24*time.Hour), 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// 삭제된 레코드의 멱등성 키는 다시 사용할 수 있습니다.
+	inserted, err := store.Insert(ctx, []outbox.Record{newRecord("old", now), newRecord("recent", now)})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, inserted)
+}
+
+func testBatchPublishedAndReleased(t *testing.T, store outbox.OutboxStore, batches outbox.BatchStore) {
+	ctx := context.Background()
+	now := time.Now().UTC().Add(-time.Minute)
+
+	first := newRecord("", now)
+	second := newRecord("", now.Add(time.Second))
+	_, err := store.Insert(ctx, []outbox.Record{first, second})
+	require.NoError(t, err)
+
+	claimed, err := store.ClaimBatch(ctx, 1, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []string{first.ID}, ids(claimed))
+
+	batchID := uuid.NewString()
+	require.NoError(t, batches.AssignBatch(ctx, batchID, ids(claimed)))
+
+	n, err := batches.MarkBatchPublished(ctx, batchID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// 이미 발행 완료된 배치를 다시 표시해도 아무 일도 일어나지 않습니다.
+	n, err = batches.MarkBatchPublished(ctx, batchID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	// 선점을 풀면 lease가 남아 있어도 시도 횟수 변화 없이 바로 다시 선점됩니다.
+	claimed, err = store.ClaimBatch(ctx, 10, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []string{second.ID}, ids(claimed))
+	require.NoError(t, batches.Release(ctx, ids(claimed)))
+
+	reclaimed, err := store.ClaimBatch(ctx, 10, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []string{second.ID}, ids(reclaimed))
+	assert.Equal(t, 0, reclaimed[0].Attempts)
+}
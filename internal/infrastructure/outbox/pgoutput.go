@@ -0,0 +1,296 @@
+package outbox
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 이 파일은 PostgreSQL 논리 복제 스트림과 pgoutput 플러그인(프로토콜 버전 1)의 메시지 중
+// CDC relay에 필요한 부분만 해석합니다.
+// https://www.postgresql.org/docs/current/protocol-replication.html
+// https://www.postgresql.org/docs/current/protocol-logicalrep-message-formats.html
+
+// LSN은 PostgreSQL WAL 위치입니다.
+type LSN uint64
+
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l))
+}
+
+// ParseLSN은 "16/B374D848" 형식의 WAL 위치를 해석합니다.
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid LSN: %s", s)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN: %s", s)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN: %s", s)
+	}
+	return LSN(h<<32 | l), nil
+}
+
+const (
+	xlogDataID      = 'w'
+	keepaliveID     = 'k'
+	standbyStatusID = 'r'
+)
+
+// 복제 프로토콜의 시각은 2000-01-01 UTC 기준 마이크로초입니다.
+var pgEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var errShortMessage = errors.New("replication message too short")
+
+// wireReader는 big-endian 복제 메시지를 순서대로 읽습니다. 처음 발생한 오류만 기록합니다.
+type wireReader struct {
+	b   []byte
+	err error
+}
+
+func (r *wireReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.b) < n {
+		r.err = errShortMessage
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *wireReader) uint8() uint8 {
+	if v := r.next(1); v != nil {
+		return v[0]
+	}
+	return 0
+}
+
+func (r *wireReader) uint16() uint16 {
+	if v := r.next(2); v != nil {
+		return binary.BigEndian.Uint16(v)
+	}
+	return 0
+}
+
+func (r *wireReader) uint32() uint32 {
+	if v := r.next(4); v != nil {
+		return binary.BigEndian.Uint32(v)
+	}
+	return 0
+}
+
+func (r *wireReader) uint64() uint64 {
+	if v := r.next(8); v != nil {
+		return binary.BigEndian.Uint64(v)
+	}
+	return 0
+}
+
+func (r *wireReader) cstring() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, c := range r.b {
+		if c == 0 {
+			s := string(r.b[:i])
+			r.b = r.b[i+1:]
+			return s
+		}
+	}
+	r.err = errShortMessage
+	return ""
+}
+
+// xlogData는 WAL 데이터를 담은 복제 메시지입니다. Data는 pgoutput 메시지입니다.
+type xlogData struct {
+	WALStart     LSN
+	ServerWALEnd LSN
+	Data         []byte
+}
+
+func parseXLogData(b []byte) (xlogData, error) {
+	r := &wireReader{b: b}
+	msg := xlogData{
+		WALStart:     LSN(r.uint64()),
+		ServerWALEnd: LSN(r.uint64()),
+	}
+	r.uint64() // 서버 전송 시각
+	msg.Data = r.b
+	return msg, r.err
+}
+
+// primaryKeepalive는 서버가 주기적으로 보내는 상태 메시지입니다.
+type primaryKeepalive struct {
+	ServerWALEnd   LSN
+	ReplyRequested bool
+}
+
+func parseKeepalive(b []byte) (primaryKeepalive, error) {
+	r := &wireReader{b: b}
+	msg := primaryKeepalive{ServerWALEnd: LSN(r.uint64())}
+	r.uint64() // 서버 전송 시각
+	msg.ReplyRequested = r.uint8() == 1
+	return msg, r.err
+}
+
+// encodeStandbyStatus는 lsn까지 처리했음을 서버에 알리는 메시지를 만듭니다.
+// 서버는 이 위치 이전의 WAL을 더 이상 보관하지 않으며, 재시작 시 이 위치부터 전송합니다.
+func encodeStandbyStatus(lsn LSN, now time.Time) []byte {
+	b := make([]byte, 0, 34)
+	b = append(b, standbyStatusID)
+	b = binary.BigEndian.AppendUint64(b, uint64(lsn)) // 수신 위치
+	b = binary.BigEndian.AppendUint64(b, uint64(lsn)) // 기록 위치
+	b = binary.BigEndian.AppendUint64(b, uint64(lsn)) // 적용 위치
+	b = binary.BigEndian.AppendUint64(b, uint64(now.Sub(pgEpoch).Microseconds()))
+	return append(b, 0)
+}
+
+// pgoutput 메시지
+type (
+	pgBegin struct {
+		FinalLSN LSN
+		XID      uint32
+	}
+
+	pgCommit struct {
+		CommitLSN LSN
+		EndLSN    LSN
+	}
+
+	pgRelation struct {
+		ID        uint32
+		Namespace string
+		Name      string
+		Columns   []string
+	}
+
+	pgInsert struct {
+		RelationID uint32
+		// Values는 컬럼 순서대로의 텍스트 값이며, NULL이면 nil입니다.
+		Values []*string
+	}
+)
+
+// parsePgoutput은 pgoutput 메시지를 해석합니다. relay가 사용하지 않는 메시지 종류는 nil을 반환합니다.
+func parsePgoutput(b []byte) (any, error) {
+	if len(b) == 0 {
+		return nil, errShortMessage
+	}
+
+	r := &wireReader{b: b[1:]}
+	var msg any
+	switch b[0] {
+	case 'B':
+		begin := pgBegin{FinalLSN: LSN(r.uint64())}
+		r.uint64() // 커밋 시각
+		begin.XID = r.uint32()
+		msg = begin
+	case 'C':
+		r.uint8() // flags
+		msg = pgCommit{
+			CommitLSN: LSN(r.uint64()),
+			EndLSN:    LSN(r.uint64()),
+		}
+	case 'R':
+		rel := pgRelation{
+			ID:        r.uint32(),
+			Namespace: r.cstring(),
+			Name:      r.cstring(),
+		}
+		r.uint8() // replica identity
+		n := int(r.uint16())
+		for i := 0; i < n && r.err == nil; i++ {
+			r.uint8() // flags
+			rel.Columns = append(rel.Columns, r.cstring())
+			r.uint32() // 타입 OID
+			r.uint32() // typmod
+		}
+		msg = rel
+	case 'I':
+		insert := pgInsert{RelationID: r.uint32()}
+		if kind := r.uint8(); r.err == nil && kind != 'N' {
+			return nil, fmt.Errorf("unexpected insert tuple kind: %c", kind)
+		}
+		insert.Values = parseTuple(r)
+		msg = insert
+	default:
+		return nil, nil
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to parse pgoutput message %c: %w", b[0], r.err)
+	}
+	return msg, nil
+}
+
+func parseTuple(r *wireReader) []*string {
+	n := int(r.uint16())
+	values := make([]*string, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		switch r.uint8() {
+		case 't':
+			v := string(r.next(int(r.uint32())))
+			values = append(values, &v)
+		default:
+			// NULL('n')이거나 변경되지 않은 TOAST 값('u')입니다. INSERT에서는 NULL만 옵니다.
+			values = append(values, nil)
+		}
+	}
+	return values
+}
+
+// recordFromInsert는 event_outbox INSERT 튜플을 Record로 변환합니다.
+func recordFromInsert(rel pgRelation, insert pgInsert) (Record, error) {
+	var rec Record
+	for i, column := range rel.Columns {
+		if i >= len(insert.Values) || insert.Values[i] == nil {
+			continue
+		}
+		v := *insert.Values[i]
+
+		switch column {
+		case "id":
+			rec.ID = v
+		case "aggregate_type":
+			rec.AggregateType = v
+		case "aggregate_id":
+			rec.AggregateID = v
+		case "type":
+			rec.Type = v
+		case "idempotency_key":
+			rec.IdempotencyKey = v
+		case "message_key":
+			rec.MessageKey = v
+		case "payload":
+			// bytea의 텍스트 표현은 "\x" 접두사가 붙은 16진수입니다.
+			payload, err := hex.DecodeString(strings.TrimPrefix(v, `\x`))
+			if err != nil {
+				return Record{}, fmt.Errorf("failed to decode payload of event %s: %w", rec.ID, err)
+			}
+			rec.Payload = payload
+		case "deliver_at":
+			// 복제 연결의 TimeZone을 UTC로 설정하므로 시간대는 항상 +00으로 전달됩니다.
+			deliverAt, err := time.Parse("2006-01-02 15:04:05.999999999-07", v)
+			if err != nil {
+				return Record{}, fmt.Errorf("failed to decode deliver_at of event %s: %w", rec.ID, err)
+			}
+			rec.DeliverAt = deliverAt.UTC()
+		}
+	}
+
+	if rec.ID == "" {
+		return Record{}, fmt.Errorf("insert into %s.%s has no id column", rel.Namespace, rel.Name)
+	}
+	return rec, nil
+}
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pgoutputBuilder는 테스트용 pgoutput 메시지를 만듭니다.
+type pgoutputBuilder []byte
+
+func (b pgoutputBuilder) u8(v uint8) pgoutputBuilder   { return append(b, v) }
+func (b pgoutputBuilder) u16(v uint16) pgoutputBuilder { return binary.BigEndian.AppendUint16(b, v) }
+func (b pgoutputBuilder) u32(v uint32) pgoutputBuilder { return binary.BigEndian.AppendUint32(b, v) }
+func (b pgoutputBuilder) u64(v uint64) pgoutputBuilder { return binary.BigEndian.AppendUint64(b, v) }
+func (b pgoutputBuilder) str(v string) pgoutputBuilder { return append(append(b, v...), 0) }
+func (b pgoutputBuilder) text(v string) pgoutputBuilder {
+	return append(b.u8('t').u32(uint32(len(v))), v...)
+}
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := ParseLSN("16/B374D848")
+	require.NoError(t, err)
+	assert.Equal(t, LSN(0x16B374D848), lsn)
+	assert.Equal(t, "16/B374D848", lsn.String())
+
+	_, err = ParseLSN("16B374D848")
+	assert.Error(t, err)
+}
+
+func TestParsePgoutput_InsertIntoOutbox(t *testing.T) {
+	columns := []string{"id", "aggregate_type", "aggregate_id", "type", "payload", "idempotency_key", "last_error", "deliver_at", "message_key"}
+
+	relation := pgoutputBuilder{'R'}.u32(16384).str("public").str("event_outbox").u8('d').u16(uint16(len(columns)))
+	for _, c := range columns {
+		relation = relation.u8(0).str(c).u32(25).u32(0xFFFFFFFF)
+	}
+	decoded, err := parsePgoutput(relation)
+	require.NoError(t, err)
+	rel, ok := decoded.(pgRelation)
+	require.True(t, ok)
+	assert.Equal(t, uint32(16384), rel.ID)
+	assert.Equal(t, columns, rel.Columns)
+
+	insert := pgoutputBuilder{'I'}.u32(16384).u8('N').u16(uint16(len(columns))).
+		text("6f1c1f8e-1b7a-4f7e-9a55-0d1c2b3a4f5e").
+		text("app").
+		text("app123").
+		text("AppInstallEvent").
+		text(`\x00000000010a03`).
+		text("install-app123").
+		u8('n').
+		text("2026-10-20 09:00:00.25+00").
+		text("channel-1")
+	decoded, err = parsePgoutput(insert)
+	require.NoError(t, err)
+	ins, ok := decoded.(pgInsert)
+	require.True(t, ok)
+	assert.Nil(t, ins.Values[6])
+
+	rec, err := recordFromInsert(rel, ins)
+	require.NoError(t, err)
+	assert.Equal(t, Record{
+		ID:             "6f1c1f8e-1b7a-4f7e-9a55-0d1c2b3a4f5e",
+		AggregateType:  "app",
+		AggregateID:    "app123",
+		Type:           "AppInstallEvent",
+		Payload:        []byte{0x0, 0x0, 0x0, 0x0, 0x1, 0xa, 0x3},
+		IdempotencyKey: "install-app123",
+		MessageKey:     "channel-1",
+		DeliverAt:      time.Date(2026, 10, 20, 9, 0, 0, 250000000, time.UTC),
+	}, rec)
+}
+
+func TestParsePgoutput_BeginCommit(t *testing.T) {
+	decoded, err := parsePgoutput(pgoutputBuilder{'B'}.u64(0x100).u64(0).u32(42))
+	require.NoError(t, err)
+	assert.Equal(t, pgBegin{FinalLSN: 0x100, XID: 42}, decoded)
+
+	decoded, err = parsePgoutput(pgoutputBuilder{'C'}.u8(0).u64(0x100).u64(0x128).u64(0))
+	require.NoError(t, err)
+	assert.Equal(t, pgCommit{CommitLSN: 0x100, EndLSN: 0x128}, decoded)
+
+	// relay가 사용하지 않는 메시지는 무시합니다.
+	decoded, err = parsePgoutput(pgoutputBuilder{'O'}.u64(0x100).str("origin"))
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	_, err = parsePgoutput(pgoutputBuilder{'C'}.u8(0).u64(0x100))
+	assert.ErrorIs(t, err, errShortMessage)
+}
+
+func TestReplicationMessages(t *testing.T) {
+	xld, err := parseXLogData(pgoutputBuilder{}.u64(0x10).u64(0x20).u64(0).u8('B'))
+	require.NoError(t, err)
+	assert.Equal(t, xlogData{WALStart: 0x10, ServerWALEnd: 0x20, Data: []byte{'B'}}, xld)
+
+	keepalive, err := parseKeepalive(pgoutputBuilder{}.u64(0x30).u64(0).u8(1))
+	require.NoError(t, err)
+	assert.Equal(t, primaryKeepalive{ServerWALEnd: 0x30, ReplyRequested: true}, keepalive)
+
+	now := pgEpoch.Add(time.Second)
+	status := encodeStandbyStatus(0x40, now)
+	require.Len(t, status, 34)
+	assert.Equal(t, byte(standbyStatusID), status[0])
+	assert.Equal(t, uint64(0x40), binary.BigEndian.Uint64(status[1:9]))
+	assert.Equal(t, uint64(0x40), binary.BigEndian.Uint64(status[17:25]))
+	assert.Equal(t, uint64(time.Second.Microseconds()), binary.BigEndian.Uint64(status[25:33]))
+}
@@ -0,0 +1,334 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/lib/pq"
+)
+
+// CDCConfig는 논리 복제 기반 relay의 설정입니다.
+type CDCConfig struct {
+	// DSN은 복제 연결에 사용할 PostgreSQL 연결 문자열입니다. replication=database는 자동으로 추가됩니다.
+	DSN string
+	// Slot은 사용할 논리 복제 슬롯 이름입니다. 없으면 pgoutput 플러그인으로 생성합니다.
+	Slot string
+	// Publication은 event_outbox INSERT를 내보내는 publication 이름입니다.
+	Publication string
+	// Topic은 outbox 레코드를 발행할 Kafka 토픽입니다.
+	Topic string
+	// Topics는 이벤트 타입별 토픽이며, 없는 타입은 Topic으로 발행합니다.
+	Topics map[string]string
+	// StatusInterval은 처리한 WAL 위치를 서버에 알리는 주기입니다.
+	StatusInterval time.Duration
+	// Retry는 Kafka 발행이나 복제 연결이 실패했을 때의 재시도 간격입니다.
+	// 커밋 순서를 지켜야 하므로 실패한 트랜잭션을 건너뛰지 않고 성공할 때까지 재시도합니다.
+	Retry RetryPolicy
+}
+
+// CDCRelay는 event_outbox를 폴링하는 대신 논리 복제 슬롯에서 INSERT를 읽어 커밋 순서대로
+// Kafka로 발행합니다. 발행 상태를 테이블에 갱신하지 않으며, 마지막으로 발행한 트랜잭션의
+// WAL 위치를 event_outbox_relay_offsets와 복제 슬롯에 기록해 재시작 시 그 다음부터 이어서 읽습니다.
+// 기록 전에 종료되면 마지막 트랜잭션이 다시 발행될 수 있습니다.
+// 예약 레코드는 건너뛰므로 ScheduledOnly로 설정한 Relay를 함께 실행해야 합니다.
+type CDCRelay struct {
+	db       *sql.DB
+	producer sarama.SyncProducer
+	cfg      CDCConfig
+	logger   *slog.Logger
+}
+
+func NewCDCRelay(db *sql.DB, producer sarama.SyncProducer, cfg CDCConfig, logger *slog.Logger) *CDCRelay {
+	return &CDCRelay{
+		db:       db,
+		producer: producer,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+// Run은 ctx가 취소될 때까지 복제 스트림을 읽어 발행하며, 연결이 끊기면 다시 연결합니다.
+func (r *CDCRelay) Run(ctx context.Context) error {
+	if err := r.ensureSlot(ctx); err != nil {
+		return err
+	}
+
+	for failures := 0; ; {
+		progressed, err := r.stream(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if progressed {
+			failures = 0
+		}
+		failures++
+
+		backoff := r.cfg.Retry.Backoff(failures)
+		r.logger.Warn("outbox replication stream stopped",
+			"error", err,
+			"slot", r.cfg.Slot,
+			"retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (r *CDCRelay) ensureSlot(ctx context.Context) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1)`, r.cfg.Slot,
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check replication slot: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `SELECT pg_create_logical_replication_slot($1, 'pgoutput')`, r.cfg.Slot)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42710" {
+		// 다른 relay가 먼저 만든 경우입니다.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create replication slot %s: %w", r.cfg.Slot, err)
+	}
+
+	r.logger.Info("created outbox replication slot", "slot", r.cfg.Slot)
+	return nil
+}
+
+// LoadOffset은 마지막으로 발행을 마친 WAL 위치를 반환합니다. 기록이 없으면 0입니다.
+func (r *CDCRelay) LoadOffset(ctx context.Context) (LSN, error) {
+	var lsn string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT lsn::text FROM event_outbox_relay_offsets WHERE slot_name = $1`, r.cfg.Slot,
+	).Scan(&lsn)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load relay offset: %w", err)
+	}
+	return ParseLSN(lsn)
+}
+
+func (r *CDCRelay) saveOffset(ctx context.Context, lsn LSN) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO event_outbox_relay_offsets (slot_name, lsn, updated_at)
+        VALUES ($1, $2::pg_lsn, CURRENT_TIMESTAMP)
+        ON CONFLICT (slot_name) DO UPDATE SET lsn = EXCLUDED.lsn, updated_at = EXCLUDED.updated_at
+    `, r.cfg.Slot, lsn.String())
+	if err != nil {
+		return fmt.Errorf("failed to save relay offset: %w", err)
+	}
+	return nil
+}
+
+func (r *CDCRelay) connect(ctx context.Context) (*pgconn.PgConn, error) {
+	config, err := pgconn.ParseConfig(r.cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse replication DSN: %w", err)
+	}
+	config.RuntimeParams["replication"] = "database"
+	config.RuntimeParams["TimeZone"] = "UTC"
+
+	conn, err := pgconn.ConnectConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replication connection: %w", err)
+	}
+	return conn, nil
+}
+
+func (r *CDCRelay) startReplication(ctx context.Context, conn *pgconn.PgConn, start LSN) error {
+	query := fmt.Sprintf(`START_REPLICATION SLOT %s LOGICAL %s (proto_version '1', publication_names %s)`,
+		pq.QuoteIdentifier(r.cfg.Slot), start, pq.QuoteLiteral(r.cfg.Publication))
+
+	conn.Frontend().Send(&pgproto3.Query{String: query})
+	if err := conn.Frontend().Flush(); err != nil {
+		return fmt.Errorf("failed to start replication: %w", err)
+	}
+
+	for {
+		msg, err := conn.ReceiveMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to start replication: %w", err)
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			return nil
+		case *pgproto3.ErrorResponse:
+			return fmt.Errorf("failed to start replication: %w", pgconn.ErrorResponseToPgError(msg))
+		}
+	}
+}
+
+func (r *CDCRelay) sendStandbyStatus(conn *pgconn.PgConn, lsn LSN) error {
+	conn.Frontend().Send(&pgproto3.CopyData{Data: encodeStandbyStatus(lsn, time.Now())})
+	if err := conn.Frontend().Flush(); err != nil {
+		return fmt.Errorf("failed to send standby status: %w", err)
+	}
+	return nil
+}
+
+// stream은 복제 연결 하나로 스트림을 읽습니다. 트랜잭션을 하나 이상 발행했으면 progressed가 true입니다.
+func (r *CDCRelay) stream(ctx context.Context) (progressed bool, err error) {
+	start, err := r.LoadOffset(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	conn, err := r.connect(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	if err := r.startReplication(ctx, conn, start); err != nil {
+		return false, err
+	}
+	r.logger.Info("started outbox replication", "slot", r.cfg.Slot, "lsn", start)
+
+	var (
+		acked     = start
+		inTx      bool
+		records   []Record
+		relations = make(map[uint32]pgRelation)
+		nextSend  = time.Now().Add(r.cfg.StatusInterval)
+	)
+
+	for {
+		if !time.Now().Before(nextSend) {
+			if err := r.sendStandbyStatus(conn, acked); err != nil {
+				return progressed, err
+			}
+			nextSend = time.Now().Add(r.cfg.StatusInterval)
+		}
+
+		recvCtx, cancel := context.WithDeadline(ctx, nextSend)
+		msg, err := conn.ReceiveMessage(recvCtx)
+		cancel()
+		if err != nil {
+			if pgconn.Timeout(err) && ctx.Err() == nil {
+				continue
+			}
+			return progressed, err
+		}
+
+		var data []byte
+		switch msg := msg.(type) {
+		case *pgproto3.CopyData:
+			data = msg.Data
+		case *pgproto3.ErrorResponse:
+			return progressed, pgconn.ErrorResponseToPgError(msg)
+		default:
+			continue
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		switch data[0] {
+		case keepaliveID:
+			keepalive, err := parseKeepalive(data[1:])
+			if err != nil {
+				return progressed, err
+			}
+			// 진행 중인 트랜잭션이 없으면 그 사이의 WAL에는 발행할 것이 없으므로 위치를 앞당깁니다.
+			if !inTx && keepalive.ServerWALEnd > acked {
+				acked = keepalive.ServerWALEnd
+			}
+			if keepalive.ReplyRequested {
+				nextSend = time.Time{}
+			}
+
+		case xlogDataID:
+			xld, err := parseXLogData(data[1:])
+			if err != nil {
+				return progressed, err
+			}
+			decoded, err := parsePgoutput(xld.Data)
+			if err != nil {
+				return progressed, err
+			}
+
+			switch m := decoded.(type) {
+			case pgRelation:
+				relations[m.ID] = m
+			case pgBegin:
+				inTx = true
+				records = records[:0]
+			case pgInsert:
+				rel, ok := relations[m.RelationID]
+				if !ok {
+					return progressed, fmt.Errorf("insert for unknown relation %d", m.RelationID)
+				}
+				if rel.Name != "event_outbox" {
+					continue
+				}
+				rec, err := recordFromInsert(rel, m)
+				if err != nil {
+					return progressed, err
+				}
+				// 예약 레코드는 발행 시각에 맞춰 ScheduledOnly 폴링 relay가 발행합니다.
+				if !rec.DeliverAt.IsZero() {
+					continue
+				}
+				records = append(records, rec)
+			case pgCommit:
+				inTx = false
+				if len(records) == 0 {
+					acked = m.EndLSN
+					continue
+				}
+				if err := r.publish(ctx, records); err != nil {
+					return progressed, err
+				}
+				if err := r.saveOffset(ctx, m.EndLSN); err != nil {
+					return progressed, err
+				}
+				acked = m.EndLSN
+				progressed = true
+			}
+		}
+	}
+}
+
+// publish는 한 트랜잭션의 레코드를 발행합니다. 순서를 지키기 위해 성공하거나 ctx가 취소될 때까지 재시도합니다.
+func (r *CDCRelay) publish(ctx context.Context, records []Record) error {
+	for attempt := 1; ; attempt++ {
+		msgs := make([]*sarama.ProducerMessage, len(records))
+		for i, rec := range records {
+			msgs[i] = newProducerMessage(topicFor(r.cfg.Topics, r.cfg.Topic, rec.Type), rec)
+		}
+
+		err := r.producer.SendMessages(msgs)
+		if err == nil {
+			return nil
+		}
+
+		backoff := r.cfg.Retry.Backoff(attempt)
+		r.logger.Error("failed to publish replicated outbox events",
+			"error", err,
+			"count", len(records),
+			"attempts", attempt,
+			"retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
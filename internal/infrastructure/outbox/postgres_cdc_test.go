@@ -0,0 +1,109 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/infrastructure/outbox"
+	"github.com/hoo47/kafka_ex/internal/infrastructure/outbox/outboxtest"
+)
+
+var postgresDrops = []string{
+	`DROP PUBLICATION IF EXISTS event_outbox_pub`,
+	`DROP TABLE IF EXISTS event_outbox, event_outbox_legacy, event_outbox_keys, event_archive, event_outbox_relay_offsets CASCADE`,
+	`DROP TYPE IF EXISTS outbox_status`,
+}
+
+// 로컬 PostgreSQL이 wal_level = logical로 실행 중이어야 합니다.
+// 예: docker run -e POSTGRES_PASSWORD=postgres -p 5432:5432 postgres:16 -c wal_level=logical
+func TestCDCRelay_Postgres(t *testing.T) {
+	dsn := os.Getenv("OUTBOX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("OUTBOX_TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	outboxtest.ApplyMigrations(t, db, migrationsDir, postgresDrops...)
+
+	slot := "outbox_test_" + uuid.NewString()[:8]
+	t.Cleanup(func() {
+		db.Exec(`SELECT pg_drop_replication_slot($1)`, slot)
+	})
+
+	store := outbox.NewPostgresStore(db)
+	producer := mocks.NewSyncProducer(t, nil)
+	defer producer.Close()
+
+	received := make(chan string, 10)
+	for i := 0; i < 3; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			received <- string(msg.Headers[1].Value)
+			return nil
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	relay := outbox.NewCDCRelay(db, producer, outbox.CDCConfig{
+		DSN:            dsn,
+		Slot:           slot,
+		Publication:    "event_outbox_pub",
+		Topic:          "app.events",
+		StatusInterval: 100 * time.Millisecond,
+		Retry:          outbox.RetryPolicy{InitialBackoff: 100 * time.Millisecond},
+	}, slog.Default())
+
+	done := make(chan error)
+	go func() { done <- relay.Run(ctx) }()
+
+	// 슬롯이 만들어진 뒤의 INSERT만 전달됩니다.
+	require.Eventually(t, func() bool {
+		var exists bool
+		db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1)`, slot).Scan(&exists)
+		return exists
+	}, 10*time.Second, 50*time.Millisecond)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		rec := outbox.Record{
+			ID:             uuid.NewString(),
+			AggregateType:  "app",
+			AggregateID:    "app123",
+			Type:           "AppInstallEvent",
+			Payload:        []byte{0x0, 0x0, 0x0, 0x0, 0x1},
+			IdempotencyKey: uuid.NewString(),
+		}
+		_, err := store.Insert(ctx, []outbox.Record{rec})
+		require.NoError(t, err)
+		ids = append(ids, rec.ID)
+	}
+
+	// 커밋 순서대로 발행됩니다.
+	for _, id := range ids {
+		select {
+		case got := <-received:
+			assert.Equal(t, id, got)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for replicated event")
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		lsn, err := relay.LoadOffset(context.Background())
+		return err == nil && lsn > 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
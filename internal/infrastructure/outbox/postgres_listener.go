@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notifier는 발행할 레코드가 생겼을 때 relay를 깨웁니다.
+type Notifier interface {
+	// Wake는 relay가 즉시 다음 배치를 처리해야 할 때 신호를 받는 채널입니다.
+	Wake() <-chan struct{}
+}
+
+// PostgresListener는 PostgreSQL LISTEN으로 NotifyChannel의 알림을 받아 relay를 깨웁니다.
+// 연결이 끊기면 lib/pq가 다시 연결하며, 재연결 직후에는 그 사이 놓친 알림이 있을 수 있으므로
+// relay를 한 번 깨웁니다.
+type PostgresListener struct {
+	listener *pq.Listener
+	wake     chan struct{}
+	logger   *slog.Logger
+}
+
+func NewPostgresListener(dsn string, logger *slog.Logger) (*PostgresListener, error) {
+	l := &PostgresListener{
+		wake:   make(chan struct{}, 1),
+		logger: logger,
+	}
+
+	l.listener = pq.NewListener(dsn, time.Second, time.Minute, l.handleEvent)
+	if err := l.listener.Listen(NotifyChannel); err != nil {
+		l.listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", NotifyChannel, err)
+	}
+	return l, nil
+}
+
+func (l *PostgresListener) Wake() <-chan struct{} {
+	return l.wake
+}
+
+// Run은 ctx가 취소될 때까지 알림을 relay로 전달하고, 끝나면 연결을 닫습니다.
+func (l *PostgresListener) Run(ctx context.Context) {
+	defer l.listener.Close()
+
+	// 유휴 연결이 끊긴 것을 알아차릴 수 있도록 주기적으로 확인합니다.
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.listener.Notify:
+			// 재연결 시에는 nil 알림이 오며, 이때도 relay를 깨웁니다.
+			l.signal()
+		case <-ping.C:
+			if err := l.listener.Ping(); err != nil {
+				l.logger.Warn("outbox listener ping failed", "error", err)
+			}
+		}
+	}
+}
+
+func (l *PostgresListener) signal() {
+	// 이미 대기 중인 신호가 있으면 합칩니다.
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (l *PostgresListener) handleEvent(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventDisconnected:
+		l.logger.Warn("outbox listener disconnected", "error", err)
+	case pq.ListenerEventReconnected:
+		l.logger.Info("outbox listener reconnected")
+	case pq.ListenerEventConnectionAttemptFailed:
+		l.logger.Warn("outbox listener reconnect failed", "error", err)
+	}
+}
@@ -0,0 +1,205 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const partitionPrefix = "event_outbox_p"
+
+// Partition은 event_outbox의 월별 파티션입니다. 범위는 [Start, End)입니다.
+type Partition struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// PartitionManager는 PostgreSQL의 created_at 기준 월별 파티션을 관리합니다.
+// 파티션 이름은 event_outbox_pYYYYMM 형식이며 범위는 UTC 기준입니다.
+type PartitionManager struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewPartitionManager(db *sql.DB, logger *slog.Logger) *PartitionManager {
+	return &PartitionManager{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func monthPartition(t time.Time) Partition {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return Partition{
+		Name:  partitionPrefix + start.Format("200601"),
+		Start: start,
+		End:   start.AddDate(0, 1, 0),
+	}
+}
+
+// EnsurePartitions는 now가 속한 달부터 ahead개월 뒤까지의 파티션을 생성합니다.
+// 해당 달의 파티션이 없으면 outbox 삽입이 실패하므로 충분히 앞서 생성해야 합니다.
+func (m *PartitionManager) EnsurePartitions(ctx context.Context, now time.Time, ahead int) error {
+	for i := 0; i <= ahead; i++ {
+		p := monthPartition(now.UTC().AddDate(0, i, 0))
+		query := fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s PARTITION OF event_outbox FOR VALUES FROM (%s) TO (%s)`,
+			pq.QuoteIdentifier(p.Name),
+			pq.QuoteLiteral(p.Start.Format(time.RFC3339)),
+			pq.QuoteLiteral(p.End.Format(time.RFC3339)),
+		)
+		if _, err := m.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create partition %s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// Partitions는 event_outbox에 연결된 월별 파티션을 오래된 순서로 반환합니다.
+func (m *PartitionManager) Partitions(ctx context.Context) ([]Partition, error) {
+	rows, err := m.db.QueryContext(ctx, `
+        SELECT c.relname
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        JOIN pg_class p ON p.oid = i.inhparent
+        WHERE p.relname = 'event_outbox'
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []Partition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan partition: %w", err)
+		}
+
+		month, err := time.Parse("200601", strings.TrimPrefix(name, partitionPrefix))
+		if !strings.HasPrefix(name, partitionPrefix) || err != nil {
+			m.logger.Warn("ignoring unknown outbox partition", "partition", name)
+			continue
+		}
+		partitions = append(partitions, monthPartition(month))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].Start.Before(partitions[j].Start)
+	})
+	return partitions, nil
+}
+
+// DropExpired는 before 이전에 끝나는 파티션을 보관한 뒤 분리하고, detach가 false이면 삭제합니다.
+// 발행되지 않은 행(격리된 행 포함)이 남은 파티션은 건너뜁니다. ignoreStatus가 true이면
+// 상태를 갱신하지 않는 CDC 모드를 위해 예약 레코드만 확인합니다.
+// 처리한 파티션 이름을 반환합니다.
+func (m *PartitionManager) DropExpired(ctx context.Context, before time.Time, archiver Archiver, batchSize int, detach, ignoreStatus bool) ([]string, error) {
+	partitions, err := m.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped []string
+	for _, p := range partitions {
+		if p.End.After(before) {
+			break
+		}
+
+		condition := "status <> 'published'"
+		if ignoreStatus {
+			condition += " AND deliver_at IS NOT NULL"
+		}
+
+		var unpublished bool
+		query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s)`, pq.QuoteIdentifier(p.Name), condition)
+		if err := m.db.QueryRowContext(ctx, query).Scan(&unpublished); err != nil {
+			return dropped, fmt.Errorf("failed to check partition %s: %w", p.Name, err)
+		}
+		if unpublished {
+			m.logger.Warn("keeping expired outbox partition with unpublished events", "partition", p.Name)
+			continue
+		}
+
+		if archiver != nil {
+			if err := m.archivePartition(ctx, p, archiver, batchSize); err != nil {
+				return dropped, err
+			}
+		}
+
+		if err := m.removePartition(ctx, p, detach); err != nil {
+			return dropped, err
+		}
+		dropped = append(dropped, p.Name)
+	}
+	return dropped, nil
+}
+
+func (m *PartitionManager) archivePartition(ctx context.Context, p Partition, archiver Archiver, batchSize int) error {
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM %s
+        WHERE (created_at, id) > ($1, $2)
+        ORDER BY created_at, id
+        LIMIT $3
+    `, recordColumns, pq.QuoteIdentifier(p.Name))
+
+	// (created_at, id) 순서로 이어서 읽으므로 큰 파티션도 나누어 보관할 수 있습니다.
+	lastCreatedAt, lastID := p.Start.Add(-time.Microsecond), "00000000-0000-0000-0000-000000000000"
+	for {
+		records, err := queryRecords(ctx, m.db, query, lastCreatedAt, lastID, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to read partition %s: %w", p.Name, err)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		if err := archiver.Archive(ctx, records); err != nil {
+			return fmt.Errorf("failed to archive partition %s: %w", p.Name, err)
+		}
+
+		last := records[len(records)-1]
+		lastCreatedAt, lastID = last.CreatedAt, last.ID
+		if len(records) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (m *PartitionManager) removePartition(ctx context.Context, p Partition, detach bool) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	name := pq.QuoteIdentifier(p.Name)
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE event_outbox DETACH PARTITION `+name); err != nil {
+		return fmt.Errorf("failed to detach partition %s: %w", p.Name, err)
+	}
+	if !detach {
+		if _, err := tx.ExecContext(ctx, `DROP TABLE `+name); err != nil {
+			return fmt.Errorf("failed to drop partition %s: %w", p.Name, err)
+		}
+	}
+
+	// 파티션과 함께 해당 기간의 멱등성 키도 정리합니다. 발행되지 않아 남겨 둔 이전 파티션의
+	// 키는 재시도한 이벤트가 중복으로 들어오지 않도록 지우지 않습니다.
+	if _, err := tx.ExecContext(ctx, `DELETE FROM event_outbox_keys WHERE created_at >= $1 AND created_at < $2`, p.Start, p.End); err != nil {
+		return fmt.Errorf("failed to delete idempotency keys: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,71 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/infrastructure/outbox"
+	"github.com/hoo47/kafka_ex/internal/infrastructure/outbox/outboxtest"
+)
+
+func TestPartitionManager_DropExpiredKeepsKeysOfKeptPartitions(t *testing.T) {
+	dsn := os.Getenv("OUTBOX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("OUTBOX_TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	outboxtest.ApplyMigrations(t, db, migrationsDir, postgresDrops...)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	older, newer := thisMonth.AddDate(0, -3, 0), thisMonth.AddDate(0, -2, 0)
+
+	manager := outbox.NewPartitionManager(db, slog.Default())
+	require.NoError(t, manager.EnsurePartitions(ctx, older, 1))
+
+	record := func(createdAt time.Time) outbox.Record {
+		return outbox.Record{
+			ID:             uuid.NewString(),
+			AggregateType:  "app",
+			AggregateID:    "app123",
+			Type:           "AppInstallEvent",
+			Payload:        []byte{0x0, 0x0, 0x0, 0x0, 0x1},
+			IdempotencyKey: uuid.NewString(),
+			CreatedAt:      createdAt,
+		}
+	}
+
+	// 이전 파티션의 이벤트는 발행되지 않아 남고, 다음 파티션의 이벤트는 발행되어 삭제됩니다.
+	store := outbox.NewPostgresStore(db)
+	pending, published := record(older.Add(time.Hour)), record(newer.Add(time.Hour))
+	_, err = store.Insert(ctx, []outbox.Record{pending, published})
+	require.NoError(t, err)
+	require.NoError(t, store.MarkPublished(ctx, []string{published.ID}))
+
+	dropped, err := manager.DropExpired(ctx, newer.AddDate(0, 1, 0), nil, 100, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"event_outbox_p" + newer.Format("200601")}, dropped)
+
+	// 남겨 둔 이벤트를 재시도해도 멱등성 키가 남아 있어 중복으로 들어가지 않습니다.
+	retry := record(now)
+	retry.IdempotencyKey = pending.IdempotencyKey
+	inserted, err := store.Insert(ctx, []outbox.Record{retry})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false}, inserted)
+
+	var keys int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM event_outbox_keys WHERE idempotency_key = $1`, published.IdempotencyKey).Scan(&keys))
+	assert.Zero(t, keys)
+}
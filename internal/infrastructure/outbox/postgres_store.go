@@ -0,0 +1,21 @@
+package outbox
+
+import "database/sql"
+
+// NotifyChannel은 새 outbox 레코드가 커밋되었을 때 NOTIFY를 보내는 PostgreSQL 채널입니다.
+const NotifyChannel = "event_outbox"
+
+// event_outbox는 created_at으로 파티션되어 있으므로 멱등성 키는 event_outbox_keys에서 관리합니다.
+var postgresDialect = dialect{
+	name:           "postgres",
+	numbered:       true,
+	conflictIgnore: "ON CONFLICT (%s) DO NOTHING",
+	lockClause:     "FOR UPDATE SKIP LOCKED",
+	keysTable:      true,
+	notify:         "SELECT pg_notify('" + NotifyChannel + "', '')",
+}
+
+// NewPostgresStore는 PostgreSQL용 OutboxStore를 생성합니다.
+func NewPostgresStore(db *sql.DB) *SQLStore {
+	return newSQLStore(db, postgresDialect)
+}
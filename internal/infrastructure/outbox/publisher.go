@@ -2,81 +2,119 @@ package outbox
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/hoo47/kafka_ex/internal/domain/events"
 	"github.com/hoo47/kafka_ex/internal/schema"
+	"google.golang.org/protobuf/proto"
 )
 
 type OutboxEventPublisher struct {
-	db    *sql.DB
-	codec *schema.Codec
+	store   OutboxStore
+	codec   *schema.Codec
+	keyFunc KeyFunc
 }
 
-func NewOutboxEventPublisher(db *sql.DB, codec *schema.Codec) *OutboxEventPublisher {
-	return &OutboxEventPublisher{
-		db:    db,
+// KeyFunc는 이벤트의 메시지 키를 반환합니다. false를 반환하면 aggregate ID를 키로 사용합니다.
+type KeyFunc func(eventType string, msg proto.Message) (string, bool)
+
+// PublisherOption은 OutboxEventPublisher의 선택적인 동작을 설정합니다.
+type PublisherOption func(*OutboxEventPublisher)
+
+// WithKeyFunc는 메시지 키를 정하는 함수를 지정합니다. 반환한 키는 aggregate ID와 별도로
+// 레코드의 메시지 키로 기록되며, relay는 이 키로 발행합니다.
+func WithKeyFunc(f KeyFunc) PublisherOption {
+	return func(p *OutboxEventPublisher) {
+		p.keyFunc = f
+	}
+}
+
+func NewOutboxEventPublisher(store OutboxStore, codec *schema.Codec, opts ...PublisherOption) *OutboxEventPublisher {
+	p := &OutboxEventPublisher{
+		store: store,
 		codec: codec,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
-func (p *OutboxEventPublisher) Publish(ctx context.Context, event events.Event) error {
-	tx, err := p.db.BeginTx(ctx, nil)
+func (p *OutboxEventPublisher) Publish(ctx context.Context, event events.Event) (events.PublishStatus, error) {
+	statuses, err := p.PublishAll(ctx, []events.Event{event})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, err
 	}
-	defer tx.Rollback()
+	return statuses[0], nil
+}
 
-	if err := p.saveEvent(ctx, tx, event); err != nil {
-		return err
+func (p *OutboxEventPublisher) PublishAll(ctx context.Context, evts []events.Event) ([]events.PublishStatus, error) {
+	records := make([]Record, 0, len(evts))
+	for _, event := range evts {
+		record, err := p.newRecord(event)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
 	}
 
-	return tx.Commit()
-}
-
-func (p *OutboxEventPublisher) PublishAll(ctx context.Context, events []events.Event) error {
-	tx, err := p.db.BeginTx(ctx, nil)
+	inserted, err := p.store.Insert(ctx, records)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
 	}
-	defer tx.Rollback()
 
-	for _, event := range events {
-		if err := p.saveEvent(ctx, tx, event); err != nil {
-			return err
+	statuses := make([]events.PublishStatus, len(inserted))
+	for i, ok := range inserted {
+		if ok {
+			statuses[i] = events.StatusRecorded
+		} else {
+			statuses[i] = events.StatusDuplicate
 		}
 	}
+	return statuses, nil
+}
 
-	return tx.Commit()
+func (p *OutboxEventPublisher) Cancel(ctx context.Context, eventID string) (bool, error) {
+	return p.store.Cancel(ctx, eventID)
 }
 
-func (p *OutboxEventPublisher) saveEvent(ctx context.Context, tx *sql.Tx, event events.Event) error {
+func (p *OutboxEventPublisher) newRecord(event events.Event) (Record, error) {
 	// Proto 메시지로 변환
 	protoMsg := event.ToProto()
 
 	// Schema Registry 형식으로 직렬화
 	payload, err := p.codec.Serialize(event.Type(), protoMsg)
 	if err != nil {
-		return fmt.Errorf("failed to serialize event: %w", err)
+		return Record{}, fmt.Errorf("failed to serialize event: %w", err)
 	}
 
-	query := `
-        INSERT INTO event_outbox (id, aggregate_type, aggregate_id, type, payload)
-        VALUES ($1, $2, $3, $4, $5)
-    `
-
-	_, err = tx.ExecContext(ctx, query,
-		uuid.New(),
-		event.AggregateType(),
-		event.AggregateID(),
-		event.Type(),
-		payload,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to insert event: %w", err)
+	id := event.ID()
+	if id == "" {
+		id = uuid.NewString()
+	}
+
+	// 멱등성 키가 없으면 이벤트 ID를 키로 사용해 항상 새로 기록되도록 합니다.
+	key := event.IdempotencyKey()
+	if key == "" {
+		key = id
+	}
+
+	var messageKey string
+	if p.keyFunc != nil {
+		if k, ok := p.keyFunc(event.Type(), protoMsg); ok {
+			messageKey = k
+		}
 	}
 
-	return nil
+	return Record{
+		ID:             id,
+		AggregateType:  event.AggregateType(),
+		AggregateID:    event.AggregateID(),
+		Type:           event.Type(),
+		Payload:        payload,
+		IdempotencyKey: key,
+		MessageKey:     messageKey,
+		DeliverAt:      event.DeliverAt(),
+	}, nil
 }
@@ -0,0 +1,376 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+)
+
+// ErrTxnFatal은 트랜잭션 producer가 더 이상 사용할 수 없는 상태가 되었음을 나타냅니다.
+// 같은 transactional.id를 가진 다른 relay에 의해 차단된 경우가 대표적이며, relay는 멈추고
+// producer를 새로 만들어야 합니다.
+var ErrTxnFatal = errors.New("kafka transaction is in a fatal state")
+
+// RelayConfig는 outbox relay의 동작을 설정합니다.
+type RelayConfig struct {
+	// Topic은 outbox 레코드를 발행할 Kafka 토픽입니다.
+	Topic string
+	// Topics는 이벤트 타입별 토픽이며, 없는 타입은 Topic으로 발행합니다.
+	Topics map[string]string
+	// BatchSize는 한 번에 선점할 최대 레코드 수입니다.
+	BatchSize int
+	// PollInterval은 발행할 레코드가 없을 때 다시 조회하기까지의 대기 시간입니다.
+	PollInterval time.Duration
+	// ClaimLease는 선점한 레코드를 다른 relay가 가져가지 못하는 시간입니다.
+	// relay가 중간에 종료되어도 lease가 끝나면 다른 relay가 이어서 발행합니다.
+	ClaimLease time.Duration
+	// Retry는 발행에 실패한 레코드의 재시도 정책입니다.
+	Retry RetryPolicy
+	// ScheduledOnly가 true이면 예약 레코드만 발행합니다. 나머지 레코드를 CDC relay가
+	// 발행할 때 사용하며, 저장소가 ScheduledStore를 구현해야 합니다.
+	ScheduledOnly bool
+}
+
+// RetryPolicy는 레코드별 재시도 간격과 격리 기준을 정의합니다.
+type RetryPolicy struct {
+	// MaxAttempts번 실패한 레코드는 격리됩니다. 0이면 격리하지 않습니다.
+	MaxAttempts int
+	// InitialBackoff는 첫 실패 후 대기 시간이며, 이후 실패마다 두 배씩 늘어납니다.
+	InitialBackoff time.Duration
+	// MaxBackoff는 대기 시간의 상한입니다.
+	MaxBackoff time.Duration
+}
+
+// Backoff는 attempts번째 실패 후 다음 시도까지의 대기 시간을 반환합니다.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempts && (p.MaxBackoff == 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// Relay는 outbox 테이블의 레코드를 Kafka로 발행합니다.
+type Relay struct {
+	store    OutboxStore
+	producer sarama.SyncProducer
+	cfg      RelayConfig
+	logger   *slog.Logger
+	notifier Notifier
+
+	checkpoint *TxnCheckpoint
+	recovered  bool
+}
+
+// RelayOption은 Relay의 선택적인 동작을 설정합니다.
+type RelayOption func(*Relay)
+
+// WithNotifier는 폴링 주기를 기다리지 않고 알림을 받는 즉시 relay가 깨어나도록 합니다.
+// 알림을 놓치는 경우에 대비해 폴링은 계속 동작하므로 PollInterval은 길게 두어도 됩니다.
+func WithNotifier(n Notifier) RelayOption {
+	return func(r *Relay) {
+		r.notifier = n
+	}
+}
+
+// WithTxnCheckpoint는 트랜잭션 producer를 사용할 때 배치 ID를 Kafka 트랜잭션과 함께
+// 커밋해, 커밋 직후 relay가 종료되어도 재시작 시 해당 배치를 발행 완료로 표시하도록 합니다.
+// 체크포인트가 없으면 배치 단위의 원자성만 보장되고 종료 시점에 따라 중복이 생길 수 있습니다.
+func WithTxnCheckpoint(c *TxnCheckpoint) RelayOption {
+	return func(r *Relay) {
+		r.checkpoint = c
+	}
+}
+
+// NewRelay는 relay를 생성합니다. producer가 트랜잭션 producer이면 배치마다 하나의 Kafka
+// 트랜잭션으로 발행하므로, read_committed 컨슈머는 중단된 배치의 메시지를 보지 않습니다.
+func NewRelay(store OutboxStore, producer sarama.SyncProducer, cfg RelayConfig, logger *slog.Logger, opts ...RelayOption) *Relay {
+	r := &Relay{
+		store:    store,
+		producer: producer,
+		cfg:      cfg,
+		logger:   logger,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run은 ctx가 취소될 때까지 outbox 레코드를 반복해서 발행합니다.
+func (r *Relay) Run(ctx context.Context) error {
+	var wake <-chan struct{}
+	if r.notifier != nil {
+		wake = r.notifier.Wake()
+	}
+
+	for {
+		n, err := r.RelayOnce(ctx)
+		if errors.Is(err, ErrTxnFatal) {
+			return err
+		}
+		if err != nil {
+			r.logger.Error("failed to relay outbox events", "error", err)
+		}
+
+		// 배치가 가득 찼다면 남은 레코드가 있을 수 있으므로 바로 다음 배치를 처리합니다.
+		if err == nil && n == r.cfg.BatchSize {
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.cfg.PollInterval):
+		case <-wake:
+		}
+	}
+}
+
+// RelayOnce는 한 배치를 선점해 발행하고 선점한 레코드 수를 반환합니다.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	// 복구할 배치의 레코드가 선점 만료 후 다시 선점되기 전에 먼저 발행 완료로 표시해야 합니다.
+	if r.producer.IsTransactional() {
+		if err := r.recoverTxn(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	records, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	msgs := make([]*sarama.ProducerMessage, len(records))
+	for i, rec := range records {
+		msgs[i] = newProducerMessage(topicFor(r.cfg.Topics, r.cfg.Topic, rec.Type), rec)
+	}
+
+	if r.producer.IsTransactional() {
+		return len(records), r.relayTxn(ctx, records, msgs)
+	}
+
+	failed := sendErrors(records, r.producer.SendMessages(msgs))
+
+	published := make([]string, 0, len(records))
+	for _, rec := range records {
+		cause, ok := failed[rec.ID]
+		if !ok {
+			published = append(published, rec.ID)
+			continue
+		}
+		if err := r.recordFailure(ctx, rec, cause); err != nil {
+			return len(records), err
+		}
+	}
+
+	if err := r.store.MarkPublished(ctx, published); err != nil {
+		return len(records), err
+	}
+	return len(records), nil
+}
+
+func (r *Relay) claim(ctx context.Context) ([]Record, error) {
+	if !r.cfg.ScheduledOnly {
+		return r.store.ClaimBatch(ctx, r.cfg.BatchSize, r.cfg.ClaimLease)
+	}
+
+	store, ok := r.store.(ScheduledStore)
+	if !ok {
+		return nil, errors.New("scheduled-only relay requires a ScheduledStore")
+	}
+	return store.ClaimScheduled(ctx, r.cfg.BatchSize, r.cfg.ClaimLease)
+}
+
+// relayTxn은 배치를 하나의 Kafka 트랜잭션으로 발행합니다. 선점한 레코드에 배치 ID를 먼저
+// 기록하고, 같은 ID를 체크포인트로 트랜잭션에 포함시킨 뒤, 커밋이 끝나야 발행 완료로 표시합니다.
+// 커밋과 표시 사이에 종료되면 재시작 후 recoverTxn이 체크포인트로 표시를 마칩니다.
+func (r *Relay) relayTxn(ctx context.Context, records []Record, msgs []*sarama.ProducerMessage) error {
+	store, err := r.batchStore()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(records))
+	for i, rec := range records {
+		ids[i] = rec.ID
+	}
+
+	batchID := uuid.NewString()
+	if err := store.AssignBatch(ctx, batchID, ids); err != nil {
+		return err
+	}
+
+	if err := r.produceTxn(msgs, batchID); err != nil {
+		if r.producer.TxnStatus()&sarama.ProducerTxnFlagFatalError != 0 {
+			return fmt.Errorf("%w: %v", ErrTxnFatal, err)
+		}
+
+		// 트랜잭션이 중단되었으므로 실패 원인이 아닌 레코드도 발행되지 않았습니다.
+		// 이 레코드들은 시도 횟수를 늘리지 않고 바로 다시 선점되게 합니다.
+		failed := sendErrors(records, err)
+		var release []string
+		for _, rec := range records {
+			cause, ok := failed[rec.ID]
+			if !ok {
+				release = append(release, rec.ID)
+				continue
+			}
+			if err := r.recordFailure(ctx, rec, cause); err != nil {
+				return err
+			}
+		}
+		return store.Release(ctx, release)
+	}
+
+	_, err = store.MarkBatchPublished(ctx, batchID)
+	return err
+}
+
+func (r *Relay) produceTxn(msgs []*sarama.ProducerMessage, batchID string) error {
+	if err := r.producer.BeginTxn(); err != nil {
+		return fmt.Errorf("failed to begin kafka transaction: %w", err)
+	}
+
+	err := r.producer.SendMessages(msgs)
+	if err == nil && r.checkpoint != nil {
+		err = r.checkpoint.Add(r.producer, batchID)
+	}
+	if err == nil {
+		if err = r.producer.CommitTxn(); err == nil {
+			return nil
+		}
+	}
+
+	if r.producer.TxnStatus()&sarama.ProducerTxnFlagFatalError == 0 {
+		if abortErr := r.producer.AbortTxn(); abortErr != nil {
+			r.logger.Error("failed to abort kafka transaction", "error", abortErr)
+		}
+	}
+	return err
+}
+
+// recoverTxn은 relay가 시작된 뒤 한 번, 이전 relay가 Kafka에 커밋했지만 발행 완료로 표시하지
+// 못한 배치를 표시합니다. 배치는 순서대로 처리되므로 표시가 누락될 수 있는 배치는 마지막
+// 하나뿐입니다.
+func (r *Relay) recoverTxn(ctx context.Context) error {
+	if r.recovered || r.checkpoint == nil {
+		return nil
+	}
+
+	store, err := r.batchStore()
+	if err != nil {
+		return err
+	}
+
+	batchID, err := r.checkpoint.Last(ctx)
+	if err != nil {
+		return err
+	}
+	if batchID != "" {
+		n, err := store.MarkBatchPublished(ctx, batchID)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			r.logger.Info("recovered committed outbox batch", "batch_id", batchID, "events", n)
+		}
+	}
+
+	r.recovered = true
+	return nil
+}
+
+func (r *Relay) batchStore() (BatchStore, error) {
+	store, ok := r.store.(BatchStore)
+	if !ok {
+		return nil, errors.New("transactional relay requires a BatchStore")
+	}
+	return store, nil
+}
+
+// sendErrors는 SendMessages의 오류를 레코드 ID별 실패 원인으로 변환합니다.
+func sendErrors(records []Record, err error) map[string]error {
+	failed := make(map[string]error)
+	if err == nil {
+		return failed
+	}
+
+	var producerErrs sarama.ProducerErrors
+	if errors.As(err, &producerErrs) {
+		for _, perr := range producerErrs {
+			failed[perr.Msg.Metadata.(string)] = perr.Err
+		}
+		return failed
+	}
+
+	// 메시지별 오류를 알 수 없으면 배치 전체를 실패로 처리합니다.
+	for _, rec := range records {
+		failed[rec.ID] = err
+	}
+	return failed
+}
+
+func topicFor(topics map[string]string, fallback, eventType string) string {
+	if topic, ok := topics[eventType]; ok {
+		return topic
+	}
+	return fallback
+}
+
+func newProducerMessage(topic string, rec Record) *sarama.ProducerMessage {
+	// 같은 aggregate의 이벤트가 같은 파티션으로 가도록 aggregate ID를 키로 사용합니다.
+	// 카탈로그에서 키 필드를 지정한 이벤트는 publisher가 기록한 메시지 키를 사용합니다.
+	key := rec.AggregateID
+	if rec.MessageKey != "" {
+		key = rec.MessageKey
+	}
+	return &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(key),
+		Value: sarama.ByteEncoder(rec.Payload),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte("type"), Value: []byte(rec.Type)},
+			{Key: []byte("event_id"), Value: []byte(rec.ID)},
+		},
+		Metadata: rec.ID,
+	}
+}
+
+// recordFailure는 실패한 레코드를 재시도 대기 상태로 돌리거나, 실패 횟수가 한도에
+// 도달하면 뒤의 레코드를 막지 않도록 격리합니다.
+func (r *Relay) recordFailure(ctx context.Context, rec Record, cause error) error {
+	attempts := rec.Attempts + 1
+
+	if r.cfg.Retry.MaxAttempts > 0 && attempts >= r.cfg.Retry.MaxAttempts {
+		r.logger.Error("quarantining outbox event",
+			"error", cause,
+			"id", rec.ID,
+			"type", rec.Type,
+			"attempts", attempts)
+		return r.store.Quarantine(ctx, rec.ID, cause)
+	}
+
+	backoff := r.cfg.Retry.Backoff(attempts)
+	r.logger.Warn("failed to publish outbox event",
+		"error", cause,
+		"id", rec.ID,
+		"type", rec.Type,
+		"attempts", attempts,
+		"retry_in", backoff)
+	return r.store.MarkFailed(ctx, rec.ID, cause, time.Now().Add(backoff))
+}
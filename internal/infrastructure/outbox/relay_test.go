@@ -0,0 +1,234 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/infrastructure/outbox"
+)
+
+func insertRecords(t *testing.T, store outbox.OutboxStore) []outbox.Record {
+	now := time.Now().UTC().Add(-time.Minute)
+	records := []outbox.Record{
+		{ID: "id-1", AggregateType: "app", AggregateID: "app1", Type: "AppInstallEvent", Payload: []byte("p1"), IdempotencyKey: "k1", CreatedAt: now},
+		{ID: "id-2", AggregateType: "app", AggregateID: "app2", Type: "AppUninstallEvent", Payload: []byte("p2"), IdempotencyKey: "k2", CreatedAt: now.Add(time.Second)},
+	}
+	_, err := store.Insert(context.Background(), records)
+	require.NoError(t, err)
+	return records
+}
+
+func newRelay(store outbox.OutboxStore, producer sarama.SyncProducer) *outbox.Relay {
+	return outbox.NewRelay(store, producer, outbox.RelayConfig{
+		Topic:      "app.events",
+		BatchSize:  10,
+		ClaimLease: time.Minute,
+	}, slog.Default())
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	ctx := context.Background()
+	store := outbox.NewSQLiteStore(openSQLite(t))
+	insertRecords(t, store)
+
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "app.events", msg.Topic)
+		assert.Equal(t, sarama.StringEncoder("app1"), msg.Key)
+		assert.Equal(t, []sarama.RecordHeader{
+			{Key: []byte("type"), Value: []byte("AppInstallEvent")},
+			{Key: []byte("event_id"), Value: []byte("id-1")},
+		}, msg.Headers)
+		return nil
+	})
+	producer.ExpectSendMessageAndSucceed()
+
+	n, err := newRelay(store, producer).RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.NoError(t, producer.Close())
+
+	remaining, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestRelay_RelayOnceUsesMessageKey(t *testing.T) {
+	ctx := context.Background()
+	store := outbox.NewSQLiteStore(openSQLite(t))
+	_, err := store.Insert(ctx, []outbox.Record{{
+		ID: "id-1", AggregateType: "app", AggregateID: "app1", Type: "AppInstallEvent", Payload: []byte("p1"),
+		IdempotencyKey: "k1", MessageKey: "channel-1",
+	}})
+	require.NoError(t, err)
+
+	// 메시지 키가 기록된 레코드는 aggregate ID 대신 메시지 키로 발행합니다.
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, sarama.StringEncoder("channel-1"), msg.Key)
+		return nil
+	})
+
+	n, err := newRelay(store, producer).RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, producer.Close())
+}
+
+func TestRelay_RelayOnceBacksOffAndQuarantines(t *testing.T) {
+	ctx := context.Background()
+	store := outbox.NewSQLiteStore(openSQLite(t))
+	insertRecords(t, store)
+
+	producer := mocks.NewSyncProducer(t, nil)
+	defer producer.Close()
+
+	relay := outbox.NewRelay(store, producer, outbox.RelayConfig{
+		Topic:      "app.events",
+		BatchSize:  10,
+		ClaimLease: time.Minute,
+		Retry: outbox.RetryPolicy{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+		},
+	}, slog.Default())
+
+	// 첫 실패는 재시도 대기, 두 번째 실패는 격리됩니다.
+	for attempt := 1; attempt <= 2; attempt++ {
+		producer.ExpectSendMessageAndFail(errors.New("broker unavailable"))
+		producer.ExpectSendMessageAndFail(errors.New("broker unavailable"))
+
+		time.Sleep(10 * time.Millisecond)
+		n, err := relay.RelayOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+	}
+
+	quarantined, err := store.ListQuarantined(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, quarantined, 2)
+	assert.Equal(t, 2, quarantined[0].Attempts)
+	assert.Equal(t, "broker unavailable", quarantined[0].LastError)
+
+	remaining, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := outbox.RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	assert.Equal(t, time.Second, policy.Backoff(1))
+	assert.Equal(t, 2*time.Second, policy.Backoff(2))
+	assert.Equal(t, 8*time.Second, policy.Backoff(4))
+	assert.Equal(t, 10*time.Second, policy.Backoff(5))
+	assert.Equal(t, 10*time.Second, policy.Backoff(100))
+}
+
+type chanNotifier chan struct{}
+
+func (n chanNotifier) Wake() <-chan struct{} { return n }
+
+func TestRelay_RunWakesOnNotification(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := outbox.NewSQLiteStore(openSQLite(t))
+	producer := mocks.NewSyncProducer(t, nil)
+	defer producer.Close()
+
+	published := make(chan struct{})
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(*sarama.ProducerMessage) error {
+		close(published)
+		return nil
+	})
+
+	// 폴링 주기가 길어도 알림을 받으면 바로 발행합니다.
+	notifier := make(chanNotifier, 1)
+	relay := outbox.NewRelay(store, producer, outbox.RelayConfig{
+		Topic:        "app.events",
+		BatchSize:    10,
+		PollInterval: time.Hour,
+		ClaimLease:   time.Minute,
+	}, slog.Default(), outbox.WithNotifier(notifier))
+
+	done := make(chan error)
+	go func() { done <- relay.Run(ctx) }()
+
+	_, err := store.Insert(ctx, []outbox.Record{
+		{ID: "id-1", AggregateType: "app", AggregateID: "app1", Type: "AppInstallEvent", Payload: []byte("p1"), IdempotencyKey: "k1"},
+	})
+	require.NoError(t, err)
+	notifier <- struct{}{}
+
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not wake up on notification")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+type checkpointAdmin struct {
+	sarama.ClusterAdmin
+	batchID string
+}
+
+func (a *checkpointAdmin) ListConsumerGroupOffsets(group string, _ map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	resp := &sarama.OffsetFetchResponse{}
+	resp.AddBlock("app.events", 0, &sarama.OffsetFetchResponseBlock{Offset: 0, Metadata: a.batchID})
+	return resp, nil
+}
+
+func TestRelay_TransactionalRecoversCommittedBatch(t *testing.T) {
+	ctx := context.Background()
+	store := outbox.NewSQLiteStore(openSQLite(t))
+	records := insertRecords(t, store)
+
+	// 이전 relay가 첫 레코드의 배치를 Kafka에 커밋한 직후 종료된 상황입니다.
+	claimed, err := store.ClaimBatch(ctx, 1, time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.NoError(t, store.AssignBatch(ctx, "batch-1", []string{claimed[0].ID}))
+	time.Sleep(10 * time.Millisecond)
+
+	config := sarama.NewConfig()
+	config.Producer.Idempotent = true
+	config.Producer.Transaction.ID = "relay-1"
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Net.MaxOpenRequests = 1
+	producer := mocks.NewSyncProducer(t, config)
+	defer producer.Close()
+
+	// 선점이 만료되었어도 커밋된 배치는 다시 발행하지 않습니다.
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, sarama.StringEncoder(records[1].AggregateID), msg.Key)
+		return nil
+	})
+
+	checkpoint := outbox.NewTxnCheckpoint(&checkpointAdmin{batchID: "batch-1"}, "relay-1", "app.events")
+	relay := outbox.NewRelay(store, producer, outbox.RelayConfig{
+		Topic:      "app.events",
+		BatchSize:  10,
+		ClaimLease: time.Minute,
+	}, slog.Default(), outbox.WithTxnCheckpoint(checkpoint))
+
+	n, err := relay.RelayOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, sarama.ProducerTxnFlagReady, producer.TxnStatus())
+
+	remaining, err := store.ClaimBatch(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RetentionConfig는 발행이 끝난 outbox 레코드의 보존 정책입니다.
+type RetentionConfig struct {
+	// Period가 지난 발행 완료 레코드는 보관 후 삭제됩니다. 0이면 삭제하지 않습니다.
+	Period time.Duration
+	// Interval은 보존 작업을 실행하는 주기입니다.
+	Interval time.Duration
+	// BatchSize는 한 번에 보관하고 삭제할 최대 레코드 수입니다.
+	BatchSize int
+	// PartitionsAhead는 미리 만들어 둘 이후 월별 파티션 개수입니다.
+	PartitionsAhead int
+	// DetachPartitions가 true이면 만료된 파티션을 삭제하지 않고 분리만 합니다.
+	DetachPartitions bool
+	// IgnoreStatus가 true이면 아직 발행되지 않은 예약 레코드가 없는 한 발행 상태와 관계없이
+	// 만료된 파티션을 정리합니다. CDC relay는 발행 상태를 갱신하지 않으므로 CDC 모드에서 사용합니다.
+	IgnoreStatus bool
+}
+
+// Retention은 보존 기간이 지난 레코드를 주기적으로 보관하고 정리합니다.
+// partitions가 주어지면 행 단위 삭제 대신 파티션을 만들고 통째로 정리합니다.
+type Retention struct {
+	store      RetentionStore
+	partitions *PartitionManager
+	archiver   Archiver
+	cfg        RetentionConfig
+	logger     *slog.Logger
+	now        func() time.Time
+}
+
+func NewRetention(store RetentionStore, partitions *PartitionManager, archiver Archiver, cfg RetentionConfig, logger *slog.Logger) *Retention {
+	return &Retention{
+		store:      store,
+		partitions: partitions,
+		archiver:   archiver,
+		cfg:        cfg,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// Run은 ctx가 취소될 때까지 보존 작업을 주기적으로 실행합니다.
+func (r *Retention) Run(ctx context.Context) error {
+	for {
+		if err := r.RunOnce(ctx); err != nil {
+			r.logger.Error("failed to apply outbox retention", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.cfg.Interval):
+		}
+	}
+}
+
+// RunOnce는 보존 작업을 한 번 실행합니다.
+func (r *Retention) RunOnce(ctx context.Context) error {
+	now := r.now().UTC()
+
+	if r.partitions != nil {
+		if err := r.partitions.EnsurePartitions(ctx, now, r.cfg.PartitionsAhead); err != nil {
+			return err
+		}
+	}
+
+	if r.cfg.Period <= 0 {
+		return nil
+	}
+	before := now.Add(-r.cfg.Period)
+
+	if r.partitions != nil {
+		dropped, err := r.partitions.DropExpired(ctx, before, r.archiver, r.cfg.BatchSize, r.cfg.DetachPartitions, r.cfg.IgnoreStatus)
+		if len(dropped) > 0 {
+			r.logger.Info("removed expired outbox partitions",
+				"partitions", dropped,
+				"detached", r.cfg.DetachPartitions)
+		}
+		return err
+	}
+
+	total := 0
+	for {
+		n, err := r.store.PurgePublished(ctx, before, r.cfg.BatchSize, r.archiver)
+		total += n
+		if err != nil || n < r.cfg.BatchSize || ctx.Err() != nil {
+			if total > 0 {
+				r.logger.Info("purged published outbox events", "count", total)
+			}
+			return err
+		}
+	}
+}
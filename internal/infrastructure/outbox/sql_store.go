@@ -0,0 +1,517 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dialect는 데이터베이스별로 다른 SQL 문법을 담습니다.
+// 쿼리는 '?' placeholder로 작성하고 rebind로 각 데이터베이스 형식에 맞게 변환합니다.
+type dialect struct {
+	name string
+	// numbered가 true이면 placeholder를 $1, $2 ... 형식으로 변환합니다.
+	numbered bool
+	// conflictIgnore는 고유 키 충돌 시 삽입을 건너뛰기 위한 구문이며, %s는 충돌 컬럼입니다.
+	conflictIgnore string
+	// keysTable이 true이면 멱등성 키를 event_outbox_keys 테이블에서 따로 관리합니다.
+	// 파티션 테이블의 고유 인덱스는 파티션 키를 포함해야 하므로 전역 중복 제거에 쓸 수 없습니다.
+	keysTable bool
+	// notify는 새로 발행할 레코드가 생겼음을 relay에 알리는 구문입니다. 트랜잭션 안에서
+	// 실행되며 커밋될 때 전달됩니다.
+	notify string
+	// lockClause는 배치 선점 시 다른 relay와 경합하지 않도록 행을 잠그는 구문입니다.
+	lockClause string
+}
+
+func (d dialect) ignoreConflict(column string) string {
+	return fmt.Sprintf(d.conflictIgnore, column)
+}
+
+func (d dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SQLStore는 database/sql 기반의 OutboxStore 구현입니다.
+// 데이터베이스별 차이는 dialect로 분리되어 있으며 생성자는 각 데이터베이스 파일에 있습니다.
+type SQLStore struct {
+	db      *sql.DB
+	dialect dialect
+	now     func() time.Time
+}
+
+func newSQLStore(db *sql.DB, d dialect) *SQLStore {
+	return &SQLStore{
+		db:      db,
+		dialect: d,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+const recordColumns = `id, aggregate_type, aggregate_id, type, payload, idempotency_key, created_at,
+        status, attempts, last_error, next_attempt_at, deliver_at, message_key`
+
+func (s *SQLStore) Insert(ctx context.Context, records []Record) ([]bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO event_outbox (id, aggregate_type, aggregate_id, type, payload, idempotency_key, created_at,
+            status, next_attempt_at, deliver_at, message_key)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `
+	if !s.dialect.keysTable {
+		query += s.dialect.ignoreConflict("idempotency_key")
+	}
+	query = s.dialect.rebind(query)
+
+	keyQuery := s.dialect.rebind(`
+        INSERT INTO event_outbox_keys (idempotency_key, event_id, created_at)
+        VALUES (?, ?, ?)
+        ` + s.dialect.ignoreConflict("idempotency_key"))
+
+	now := s.now()
+	inserted := make([]bool, len(records))
+	for i, rec := range records {
+		createdAt := rec.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+
+		// 예약 레코드는 발행 시각을 next_attempt_at으로 두어 그 전에는 선점되지 않게 합니다.
+		nextAttemptAt := now
+		var deliverAt sql.NullTime
+		if !rec.DeliverAt.IsZero() {
+			deliverAt = sql.NullTime{Time: rec.DeliverAt.UTC(), Valid: true}
+			if rec.DeliverAt.After(now) {
+				nextAttemptAt = rec.DeliverAt.UTC()
+			}
+		}
+
+		if s.dialect.keysTable {
+			ok, err := execInserted(ctx, tx, keyQuery, rec.IdempotencyKey, rec.ID, createdAt.UTC())
+			if err != nil {
+				return nil, fmt.Errorf("failed to insert idempotency key: %w", err)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		ok, err := execInserted(ctx, tx, query,
+			rec.ID,
+			rec.AggregateType,
+			rec.AggregateID,
+			rec.Type,
+			rec.Payload,
+			rec.IdempotencyKey,
+			createdAt.UTC(),
+			StatusPending,
+			nextAttemptAt,
+			deliverAt,
+			sql.NullString{String: rec.MessageKey, Valid: rec.MessageKey != ""},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert event: %w", err)
+		}
+		inserted[i] = ok
+	}
+
+	if err := s.notifyTx(ctx, tx, inserted...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return inserted, nil
+}
+
+func (s *SQLStore) ClaimBatch(ctx context.Context, limit int, lease time.Duration) ([]Record, error) {
+	return s.claim(ctx, limit, lease, "")
+}
+
+func (s *SQLStore) ClaimScheduled(ctx context.Context, limit int, lease time.Duration) ([]Record, error) {
+	return s.claim(ctx, limit, lease, "AND deliver_at IS NOT NULL")
+}
+
+func (s *SQLStore) claim(ctx context.Context, limit int, lease time.Duration, filter string) ([]Record, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// in_flight 레코드의 next_attempt_at은 선점 만료 시각이므로, 선점한 relay가 중간에
+	// 종료되면 lease가 끝난 뒤 다시 선점됩니다.
+	now := s.now()
+	query := s.dialect.rebind(`
+        SELECT ` + recordColumns + `
+        FROM event_outbox
+        WHERE status IN (?, ?, ?)
+          AND next_attempt_at <= ?
+          ` + filter + `
+        ORDER BY created_at
+        LIMIT ?
+        ` + s.dialect.lockClause)
+
+	records, err := queryRecords(ctx, tx, query,
+		StatusPending, StatusInFlight, StatusFailed, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(records))
+	for i, rec := range records {
+		ids[i] = rec.ID
+	}
+
+	placeholders, args := inClause(ids)
+	update := s.dialect.rebind(`UPDATE event_outbox SET status = ?, next_attempt_at = ? WHERE id IN (` + placeholders + `)`)
+	if _, err := tx.ExecContext(ctx, update, append([]any{StatusInFlight, now.Add(lease)}, args...)...); err != nil {
+		return nil, fmt.Errorf("failed to claim events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for i := range records {
+		records[i].Status = StatusInFlight
+		records[i].NextAttemptAt = now.Add(lease)
+	}
+	return records, nil
+}
+
+func (s *SQLStore) MarkPublished(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders, args := inClause(ids)
+	query := s.dialect.rebind(`UPDATE event_outbox SET status = ?, last_error = NULL WHERE id IN (` + placeholders + `)`)
+	if _, err := s.db.ExecContext(ctx, query, append([]any{StatusPublished}, args...)...); err != nil {
+		return fmt.Errorf("failed to mark events published: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) AssignBatch(ctx context.Context, batchID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders, args := inClause(ids)
+	query := s.dialect.rebind(`UPDATE event_outbox SET relay_batch = ? WHERE id IN (` + placeholders + `)`)
+	if _, err := s.db.ExecContext(ctx, query, append([]any{batchID}, args...)...); err != nil {
+		return fmt.Errorf("failed to assign relay batch: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) MarkBatchPublished(ctx context.Context, batchID string) (int64, error) {
+	query := s.dialect.rebind(`
+        UPDATE event_outbox
+        SET status = ?, last_error = NULL
+        WHERE relay_batch = ? AND status = ?
+    `)
+	result, err := s.db.ExecContext(ctx, query, StatusPublished, batchID, StatusInFlight)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark relay batch published: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check published rows: %w", err)
+	}
+	return n, nil
+}
+
+func (s *SQLStore) Release(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// in_flight 레코드는 next_attempt_at이 지나면 다시 선점되므로 선점 만료 시각만 당깁니다.
+	placeholders, args := inClause(ids)
+	query := s.dialect.rebind(`UPDATE event_outbox SET next_attempt_at = ? WHERE status = ? AND id IN (` + placeholders + `)`)
+	if _, err := s.db.ExecContext(ctx, query, append([]any{s.now(), StatusInFlight}, args...)...); err != nil {
+		return fmt.Errorf("failed to release events: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) MarkFailed(ctx context.Context, id string, cause error, retryAt time.Time) error {
+	query := s.dialect.rebind(`
+        UPDATE event_outbox
+        SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+        WHERE id = ?
+    `)
+	if _, err := s.db.ExecContext(ctx, query, StatusFailed, errorText(cause), retryAt.UTC(), id); err != nil {
+		return fmt.Errorf("failed to mark event failed: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Quarantine(ctx context.Context, id string, cause error) error {
+	query := s.dialect.rebind(`
+        UPDATE event_outbox
+        SET status = ?, attempts = attempts + 1, last_error = ?
+        WHERE id = ?
+    `)
+	if _, err := s.db.ExecContext(ctx, query, StatusQuarantined, errorText(cause), id); err != nil {
+		return fmt.Errorf("failed to quarantine event: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) ListQuarantined(ctx context.Context, limit int) ([]Record, error) {
+	query := s.dialect.rebind(`
+        SELECT ` + recordColumns + `
+        FROM event_outbox
+        WHERE status = ?
+        ORDER BY created_at
+        LIMIT ?
+    `)
+	return queryRecords(ctx, s.db, query, StatusQuarantined, limit)
+}
+
+func (s *SQLStore) PurgePublished(ctx context.Context, before time.Time, limit int, archiver Archiver) (int, error) {
+	query := s.dialect.rebind(`
+        SELECT ` + recordColumns + `
+        FROM event_outbox
+        WHERE status = ? AND created_at < ?
+        ORDER BY created_at
+        LIMIT ?
+    `)
+	records, err := queryRecords(ctx, s.db, query, StatusPublished, before.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	if archiver != nil {
+		if err := archiver.Archive(ctx, records); err != nil {
+			return 0, fmt.Errorf("failed to archive events: %w", err)
+		}
+	}
+
+	ids := make([]string, len(records))
+	for i, rec := range records {
+		ids[i] = rec.ID
+	}
+	placeholders, args := inClause(ids)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, s.dialect.rebind(`DELETE FROM event_outbox WHERE id IN (`+placeholders+`)`), args...); err != nil {
+		return 0, fmt.Errorf("failed to delete events: %w", err)
+	}
+	if s.dialect.keysTable {
+		if _, err := tx.ExecContext(ctx, s.dialect.rebind(`DELETE FROM event_outbox_keys WHERE event_id IN (`+placeholders+`)`), args...); err != nil {
+			return 0, fmt.Errorf("failed to delete idempotency keys: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(records), nil
+}
+
+func (s *SQLStore) Requeue(ctx context.Context, ids []string) (int64, error) {
+	query := `UPDATE event_outbox SET status = ?, attempts = 0, next_attempt_at = ? WHERE status = ?`
+	args := []any{StatusPending, s.now(), StatusQuarantined}
+	if len(ids) > 0 {
+		placeholders, idArgs := inClause(ids)
+		query += ` AND id IN (` + placeholders + `)`
+		args = append(args, idArgs...)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, s.dialect.rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue events: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check requeued rows: %w", err)
+	}
+
+	if err := s.notifyTx(ctx, tx, n > 0); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return n, nil
+}
+
+func (s *SQLStore) Cancel(ctx context.Context, id string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := s.dialect.rebind(`
+        DELETE FROM event_outbox
+        WHERE id = ? AND deliver_at IS NOT NULL AND status IN (?, ?, ?)
+    `)
+	result, err := tx.ExecContext(ctx, query, id, StatusPending, StatusFailed, StatusQuarantined)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel event: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check cancelled rows: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	// 취소된 이벤트의 멱등성 키는 다시 사용할 수 있습니다.
+	if s.dialect.keysTable {
+		if _, err := tx.ExecContext(ctx, s.dialect.rebind(`DELETE FROM event_outbox_keys WHERE event_id = ?`), id); err != nil {
+			return false, fmt.Errorf("failed to delete idempotency key: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// notifyTx는 changed 중 하나라도 true이면 relay에 알림을 보냅니다.
+func (s *SQLStore) notifyTx(ctx context.Context, tx *sql.Tx, changed ...bool) error {
+	if s.dialect.notify == "" {
+		return nil
+	}
+	for _, ok := range changed {
+		if ok {
+			if _, err := tx.ExecContext(ctx, s.dialect.notify); err != nil {
+				return fmt.Errorf("failed to notify relay: %w", err)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// execInserted는 삽입 쿼리를 실행하고 행이 실제로 삽입되었는지 반환합니다.
+func execInserted(ctx context.Context, tx *sql.Tx, query string, args ...any) (bool, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// queryer는 *sql.DB와 *sql.Tx의 공통 조회 메서드입니다.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func queryRecords(ctx context.Context, q queryer, query string, args ...any) ([]Record, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select events: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var (
+			rec        Record
+			status     string
+			lastError  sql.NullString
+			deliverAt  sql.NullTime
+			messageKey sql.NullString
+		)
+		if err := rows.Scan(
+			&rec.ID,
+			&rec.AggregateType,
+			&rec.AggregateID,
+			&rec.Type,
+			&rec.Payload,
+			&rec.IdempotencyKey,
+			&rec.CreatedAt,
+			&status,
+			&rec.Attempts,
+			&lastError,
+			&rec.NextAttemptAt,
+			&deliverAt,
+			&messageKey,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		rec.Status = Status(status)
+		rec.LastError = lastError.String
+		rec.DeliverAt = deliverAt.Time
+		rec.MessageKey = messageKey.String
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+	return records, nil
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func inClause(ids []string) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "), args
+}
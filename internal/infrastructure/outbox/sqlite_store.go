@@ -0,0 +1,15 @@
+package outbox
+
+import "database/sql"
+
+// SQLite는 쓰기 트랜잭션이 데이터베이스 전체를 잠그므로 행 잠금 구문이 없습니다.
+var sqliteDialect = dialect{
+	name:           "sqlite",
+	conflictIgnore: "ON CONFLICT (%s) DO NOTHING",
+}
+
+// NewSQLiteStore는 SQLite용 OutboxStore를 생성합니다.
+// 동시 쓰기를 지원하지 않으므로 db.SetMaxOpenConns(1)과 함께 사용해야 합니다.
+func NewSQLiteStore(db *sql.DB) *SQLStore {
+	return newSQLStore(db, sqliteDialect)
+}
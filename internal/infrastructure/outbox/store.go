@@ -0,0 +1,118 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Status는 outbox 레코드의 발행 상태입니다.
+type Status string
+
+const (
+	// StatusPending은 아직 발행을 시도하지 않은 상태입니다.
+	StatusPending Status = "pending"
+	// StatusInFlight는 relay가 선점해 발행 중인 상태입니다.
+	StatusInFlight Status = "in_flight"
+	// StatusPublished는 Kafka로 발행이 완료된 상태입니다.
+	StatusPublished Status = "published"
+	// StatusFailed는 발행에 실패해 재시도를 기다리는 상태입니다.
+	StatusFailed Status = "failed"
+	// StatusQuarantined는 반복된 실패로 격리되어 더 이상 자동으로 재시도하지 않는 상태입니다.
+	StatusQuarantined Status = "quarantined"
+)
+
+// Record는 outbox 테이블의 한 행입니다.
+type Record struct {
+	ID             string
+	AggregateType  string
+	AggregateID    string
+	Type           string
+	Payload        []byte
+	IdempotencyKey string
+	MessageKey     string // 비어 있으면 AggregateID를 Kafka 메시지 키로 사용합니다.
+	CreatedAt      time.Time
+	DeliverAt      time.Time
+
+	Status        Status
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+}
+
+// OutboxStore는 outbox 테이블에 대한 저장소 연산을 추상화합니다.
+// publisher와 relay는 이 인터페이스에만 의존하므로 데이터베이스를 교체할 수 있습니다.
+type OutboxStore interface {
+	// Insert는 레코드들을 하나의 트랜잭션으로 저장합니다. 같은 멱등성 키가 이미 있는
+	// 레코드는 무시되며, 반환값은 입력 순서대로 각 레코드가 새로 기록되었는지를 나타냅니다.
+	Insert(ctx context.Context, records []Record) ([]bool, error)
+
+	// ClaimBatch는 발행 시각이 된 레코드를 생성 순서대로 최대 limit개 선점합니다.
+	// 선점된 레코드는 lease가 끝날 때까지 다른 relay에 반환되지 않습니다.
+	ClaimBatch(ctx context.Context, limit int, lease time.Duration) ([]Record, error)
+
+	// MarkPublished는 레코드들을 발행 완료로 표시합니다.
+	MarkPublished(ctx context.Context, ids []string) error
+
+	// MarkFailed는 발행 실패를 기록하고 retryAt 이후에 다시 시도되도록 합니다.
+	MarkFailed(ctx context.Context, id string, cause error, retryAt time.Time) error
+
+	// Quarantine은 발행 실패를 기록하고 레코드를 격리합니다.
+	// 격리된 레코드는 Requeue되기 전까지 선점되지 않습니다.
+	Quarantine(ctx context.Context, id string, cause error) error
+
+	// ListQuarantined는 격리된 레코드를 생성 순서대로 최대 limit개 반환합니다.
+	ListQuarantined(ctx context.Context, limit int) ([]Record, error)
+
+	// Requeue는 격리된 레코드의 시도 횟수를 초기화하고 다시 발행 대기 상태로 되돌립니다.
+	// ids가 비어 있으면 격리된 모든 레코드를 되돌리며, 되돌린 레코드 수를 반환합니다.
+	Requeue(ctx context.Context, ids []string) (int64, error)
+
+	// Cancel은 아직 발행되지 않은 예약 레코드를 삭제하고 삭제되었는지를 반환합니다.
+	// 선점 중이거나 발행된 레코드, 예약되지 않은 레코드는 삭제하지 않습니다.
+	Cancel(ctx context.Context, id string) (bool, error)
+}
+
+// ScheduledStore는 예약 레코드만 선점하는 저장소 연산입니다. CDC 모드에서는 예약되지 않은
+// 레코드를 복제 슬롯에서 바로 발행하므로, 예약 레코드만 폴링 relay가 발행합니다.
+type ScheduledStore interface {
+	// ClaimScheduled는 발행 시각이 된 예약 레코드만 ClaimBatch와 같은 방식으로 선점합니다.
+	ClaimScheduled(ctx context.Context, limit int, lease time.Duration) ([]Record, error)
+}
+
+// RetentionStore는 보존 기간이 지난 레코드를 정리하는 저장소 연산입니다.
+type RetentionStore interface {
+	// PurgePublished는 before 이전에 생성되어 발행이 끝난 레코드를 최대 limit개 삭제하고
+	// 삭제한 레코드 수를 반환합니다. archiver가 있으면 삭제 전에 보관하며, 보관에 실패하면
+	// 삭제하지 않습니다.
+	PurgePublished(ctx context.Context, before time.Time, limit int, archiver Archiver) (int, error)
+}
+
+// BatchStore는 트랜잭션 relay가 Kafka 트랜잭션과 발행 완료 표시의 순서를 맞추기 위한
+// 저장소 연산입니다.
+type BatchStore interface {
+	// AssignBatch는 선점한 레코드에 배치 ID를 기록합니다.
+	AssignBatch(ctx context.Context, batchID string, ids []string) error
+
+	// MarkBatchPublished는 batchID가 기록된 선점 중인 레코드를 발행 완료로 표시하고
+	// 표시한 레코드 수를 반환합니다. 이미 발행 완료된 배치에 대해서는 0을 반환합니다.
+	MarkBatchPublished(ctx context.Context, batchID string) (int64, error)
+
+	// Release는 시도 횟수를 늘리지 않고 선점을 풀어 레코드가 바로 다시 선점되게 합니다.
+	Release(ctx context.Context, ids []string) error
+}
+
+// NewStore는 database/sql 드라이버 이름에 맞는 저장소를 생성합니다.
+func NewStore(driver string, db *sql.DB) (*SQLStore, error) {
+	switch driver {
+	case "postgres", "pgx":
+		return NewPostgresStore(db), nil
+	case "mysql":
+		return NewMySQLStore(db), nil
+	case "sqlite", "sqlite3":
+		return NewSQLiteStore(db), nil
+	default:
+		return nil, fmt.Errorf("unsupported outbox driver: %s", driver)
+	}
+}
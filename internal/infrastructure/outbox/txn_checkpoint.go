@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// TxnCheckpoint는 트랜잭션 relay가 마지막으로 커밋한 배치 ID를 Kafka에 기록합니다.
+// 배치 ID는 컨슈머 그룹 오프셋의 메타데이터로 Kafka 트랜잭션에 포함되므로 배치의 메시지와
+// 함께 커밋되거나 함께 중단됩니다. 그룹은 오프셋 저장에만 쓰이며 실제 컨슈머는 없습니다.
+type TxnCheckpoint struct {
+	admin sarama.ClusterAdmin
+	group string
+	topic string
+}
+
+// NewTxnCheckpoint는 group의 topic 0번 파티션 오프셋에 배치 ID를 기록하는 체크포인트를 생성합니다.
+// relay 인스턴스마다 고유한 group을 사용해야 하며, 보통 transactional.id를 그대로 사용합니다.
+func NewTxnCheckpoint(admin sarama.ClusterAdmin, group, topic string) *TxnCheckpoint {
+	return &TxnCheckpoint{
+		admin: admin,
+		group: group,
+		topic: topic,
+	}
+}
+
+// Add는 현재 트랜잭션에 batchID를 포함시킵니다.
+func (c *TxnCheckpoint) Add(producer sarama.SyncProducer, batchID string) error {
+	offsets := map[string][]*sarama.PartitionOffsetMetadata{
+		c.topic: {{Partition: 0, Offset: 0, Metadata: &batchID}},
+	}
+	if err := producer.AddOffsetsToTxn(offsets, c.group); err != nil {
+		return fmt.Errorf("failed to add checkpoint to transaction: %w", err)
+	}
+	return nil
+}
+
+// Last는 마지막으로 커밋된 배치 ID를 반환합니다. 커밋된 배치가 없으면 빈 문자열을 반환합니다.
+func (c *TxnCheckpoint) Last(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	resp, err := c.admin.ListConsumerGroupOffsets(c.group, map[string][]int32{c.topic: {0}})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch relay checkpoint: %w", err)
+	}
+	if resp.Err != sarama.ErrNoError {
+		return "", fmt.Errorf("failed to fetch relay checkpoint: %w", resp.Err)
+	}
+
+	block := resp.GetBlock(c.topic, 0)
+	if block == nil {
+		return "", nil
+	}
+	if block.Err != sarama.ErrNoError {
+		return "", fmt.Errorf("failed to fetch relay checkpoint: %w", block.Err)
+	}
+	if block.Offset < 0 {
+		return "", nil
+	}
+	return block.Metadata, nil
+}
@@ -0,0 +1,129 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// App은 컨슈머 그룹의 수명 주기를 관리합니다.
+type App struct {
+	group           sarama.ConsumerGroup
+	handler         sarama.ConsumerGroupHandler
+	topics          []string
+	logger          *slog.Logger
+	shutdownTimeout time.Duration
+	closers         []io.Closer
+}
+
+// AppOption은 App의 선택적인 동작을 설정합니다.
+type AppOption func(*App)
+
+// WithShutdownTimeout은 종료할 때 처리 중인 메시지를 기다리는 최대 시간을 지정합니다.
+func WithShutdownTimeout(d time.Duration) AppOption {
+	return func(a *App) {
+		a.shutdownTimeout = d
+	}
+}
+
+// WithCloser는 컨슈머 그룹을 닫은 뒤 닫을 자원(producer 등)을 추가합니다.
+// 추가한 순서의 역순으로 닫습니다.
+func WithCloser(c io.Closer) AppOption {
+	return func(a *App) {
+		a.closers = append(a.closers, c)
+	}
+}
+
+func NewApp(group sarama.ConsumerGroup, handler sarama.ConsumerGroupHandler, topics []string, logger *slog.Logger, opts ...AppOption) *App {
+	a := &App{
+		group:           group,
+		handler:         handler,
+		topics:          topics,
+		logger:          logger,
+		shutdownTimeout: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Run은 ctx가 취소될 때까지 메시지를 소비한 뒤 다음 순서로 종료합니다.
+//
+//  1. 세션을 끝내 새 메시지를 가져오지 않습니다.
+//  2. 처리 중인 핸들러를 shutdownTimeout까지 기다립니다. 핸들러의 ctx는 세션과 함께 취소됩니다.
+//  3. 세션의 Cleanup에서 표시한 오프셋을 커밋합니다.
+//  4. 컨슈머 그룹과 WithCloser로 추가한 자원을 닫습니다.
+//
+// 2와 4에서 컨슈머 그룹을 닫는 시간까지 합쳐 shutdownTimeout이 지나면 더 기다리지 않습니다.
+//
+// 컨슈머 그룹이 먼저 닫히면 오류를 반환합니다.
+func (a *App) Run(ctx context.Context) error {
+	// 종료 순서를 직접 제어하기 위해 ctx와 분리된 컨텍스트로 소비합니다.
+	consumeCtx, stopConsuming := context.WithCancel(context.Background())
+	defer stopConsuming()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.consume(consumeCtx)
+	}()
+
+	// expired는 종료를 시작한 뒤 shutdownTimeout이 지나면 닫힙니다. sarama의 Close는 진행 중인
+	// Consume이 끝날 때까지 기다리므로, 핸들러가 멈춰 있으면 Close도 기다리지 않고 넘어갑니다.
+	var expired chan struct{}
+	var runErr error
+	select {
+	case <-ctx.Done():
+		a.logger.Info("stopping consumer", "timeout", a.shutdownTimeout)
+		stopConsuming()
+		expired = make(chan struct{})
+		timer := time.AfterFunc(a.shutdownTimeout, func() { close(expired) })
+		defer timer.Stop()
+		select {
+		case <-done:
+		case <-expired:
+			a.logger.Warn("timed out waiting for in-flight messages, closing consumer group")
+		}
+	case runErr = <-done:
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- a.group.Close()
+	}()
+	select {
+	case err := <-closed:
+		if err != nil && !errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			a.logger.Error("failed to close consumer group", "error", err)
+		}
+	case <-expired:
+		a.logger.Warn("timed out closing consumer group")
+	}
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i].Close(); err != nil {
+			a.logger.Error("failed to close resource", "error", err)
+		}
+	}
+	return runErr
+}
+
+// consume은 ctx가 취소될 때까지 세션을 반복합니다. 리밸런싱이 일어나면 Consume이 반환되므로
+// 다시 호출해 새 세션에 참여합니다.
+func (a *App) consume(ctx context.Context) error {
+	for {
+		err := a.group.Consume(ctx, a.topics, a.handler)
+		if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			return err
+		}
+		if err != nil {
+			a.logger.Error("error from consumer", "error", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package kafka
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGroup은 Consume에서 ctx가 취소된 뒤 drain만큼 처리 중인 메시지를 마저 처리하는 컨슈머 그룹입니다.
+// closeWaits가 true이면 sarama처럼 Close가 진행 중인 Consume이 끝날 때까지 기다립니다.
+type fakeGroup struct {
+	drain      time.Duration
+	closeWaits bool
+
+	consuming sync.WaitGroup
+
+	mu    sync.Mutex
+	steps []string
+}
+
+func (g *fakeGroup) record(step string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.steps = append(g.steps, step)
+}
+
+func (g *fakeGroup) recorded() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.steps...)
+}
+
+func (g *fakeGroup) Consume(ctx context.Context, _ []string, _ sarama.ConsumerGroupHandler) error {
+	g.consuming.Add(1)
+	defer g.consuming.Done()
+	<-ctx.Done()
+	time.Sleep(g.drain)
+	g.record("drained")
+	return nil
+}
+
+func (g *fakeGroup) Errors() <-chan error { return nil }
+
+func (g *fakeGroup) Close() error {
+	if g.closeWaits {
+		g.consuming.Wait()
+	}
+	g.record("group closed")
+	return nil
+}
+
+func (g *fakeGroup) Pause(map[string][]int32)  {}
+func (g *fakeGroup) Resume(map[string][]int32) {}
+func (g *fakeGroup) PauseAll()                 {}
+func (g *fakeGroup) ResumeAll()                {}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func runApp(t *testing.T, group *fakeGroup, timeout time.Duration) time.Duration {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	app := NewApp(group, nil, []string{"app.events"}, logger,
+		WithShutdownTimeout(timeout),
+		WithCloser(closerFunc(func() error {
+			group.record("producer closed")
+			return nil
+		})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	require.NoError(t, app.Run(ctx))
+	return time.Since(start)
+}
+
+func TestApp_RunDrainsBeforeClosing(t *testing.T) {
+	group := &fakeGroup{drain: 50 * time.Millisecond}
+	runApp(t, group, time.Second)
+
+	assert.Equal(t, []string{"drained", "group closed", "producer closed"}, group.recorded())
+}
+
+func TestApp_RunStopsWaitingAfterTimeout(t *testing.T) {
+	group := &fakeGroup{drain: time.Second}
+	elapsed := runApp(t, group, 50*time.Millisecond)
+
+	assert.Less(t, elapsed, 500*time.Millisecond)
+	// 시간이 지나면 컨슈머 그룹이 닫히기를 기다리지 않으므로 두 자원이 닫히는 순서는 정해져 있지 않습니다.
+	assert.Eventually(t, func() bool {
+		return assert.ElementsMatch(new(testing.T), []string{"group closed", "producer closed"}, group.recorded())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestApp_RunDoesNotWaitForBlockedClose(t *testing.T) {
+	group := &fakeGroup{drain: time.Second, closeWaits: true}
+	elapsed := runApp(t, group, 50*time.Millisecond)
+
+	assert.Less(t, elapsed, 500*time.Millisecond)
+	assert.Equal(t, []string{"producer closed"}, group.recorded())
+}
@@ -0,0 +1,235 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/events"
+)
+
+type batchItem struct {
+	raw *sarama.ConsumerMessage
+	msg events.Message
+}
+
+type pendingBatch struct {
+	items    []batchItem
+	deadline time.Time
+}
+
+// batcher는 한 파티션에서 받은 메시지를 이벤트 타입별 배치로 모읍니다.
+type batcher struct {
+	batches map[string]*pendingBatch
+}
+
+// add는 item을 배치에 담고, 배치가 maxSize에 도달하면 배치를 꺼내 반환합니다.
+func (b *batcher) add(eventType string, item batchItem, maxSize int, maxAge time.Duration) []batchItem {
+	if b.batches == nil {
+		b.batches = make(map[string]*pendingBatch)
+	}
+	batch, ok := b.batches[eventType]
+	if !ok {
+		batch = &pendingBatch{deadline: time.Now().Add(maxAge)}
+		b.batches[eventType] = batch
+	}
+	batch.items = append(batch.items, item)
+
+	if len(batch.items) < maxSize {
+		return nil
+	}
+	delete(b.batches, eventType)
+	return batch.items
+}
+
+// take는 eventType이 아닌 배치 중 key의 메시지를 담은 배치를 꺼내 반환합니다. 같은 키의 메시지를
+// 다른 곳에 넘기기 전에 꺼내 처리하므로 한 키의 메시지는 많아야 한 배치에만 남아 있습니다.
+func (b *batcher) take(key []byte, eventType string) (string, []batchItem, bool) {
+	for pendingType, batch := range b.batches {
+		if pendingType == eventType {
+			continue
+		}
+		for _, item := range batch.items {
+			if bytes.Equal(item.raw.Key, key) {
+				delete(b.batches, pendingType)
+				return pendingType, batch.items, true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// nextDeadline은 가장 먼저 최대 대기 시간에 도달하는 배치의 시각을 반환합니다.
+func (b *batcher) nextDeadline() (time.Time, bool) {
+	var next time.Time
+	for _, batch := range b.batches {
+		if next.IsZero() || batch.deadline.Before(next) {
+			next = batch.deadline
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// due는 최대 대기 시간에 도달한 배치를 꺼내 반환합니다.
+func (b *batcher) due(now time.Time) map[string][]batchItem {
+	due := make(map[string][]batchItem)
+	for eventType, batch := range b.batches {
+		if !batch.deadline.After(now) {
+			due[eventType] = batch.items
+			delete(b.batches, eventType)
+		}
+	}
+	return due
+}
+
+// addToBatch는 메시지를 역직렬화해 배치에 담고, 배치가 가득 차면 flush로 바로 처리합니다.
+// 역직렬화에 실패한 메시지는 배치에 담지 않고 DLQ로 보냅니다.
+func (c *Consumer) addToBatch(ctx context.Context, batches *batcher, eventType string, msg *sarama.ConsumerMessage, complete func(*sarama.ConsumerMessage), flush func(string, []batchItem)) {
+	event, err := c.codec.Deserialize(msg.Value, eventType)
+	if err != nil {
+		if c.fail(ctx, msg, deserializeError(err)) == nil {
+			complete(msg)
+		}
+		return
+	}
+
+	policy := c.policy.Load()
+	item := batchItem{
+		raw: msg,
+		msg: events.Message{
+			Event:     event,
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+			Key:       msg.Key,
+			Timestamp: msg.Timestamp,
+		},
+	}
+	if items := batches.add(eventType, item, policy.BatchSize, policy.BatchMaxAge); items != nil {
+		flush(eventType, items)
+	}
+}
+
+// flushBatch는 배치 핸들러를 호출하고, 실패한 메시지만 모아 MaxAttempts까지 재시도합니다.
+// 성공한 메시지는 바로 끝난 것으로 기록하므로 배치의 모든 메시지가 끝나야 워터마크가 배치 뒤로 넘어갑니다.
+// 메시지별 오류는 process와 같이 분류해, events.Skip은 성공으로 보고 events.Permanent는 재시도하지 않고
+// DLQ로 보냅니다.
+func (c *Consumer) flushBatch(ctx context.Context, eventType string, items []batchItem, complete func(*sarama.ConsumerMessage)) {
+	policy := c.policy.Load()
+	breaker := c.breakerFor(eventType, policy)
+
+	for attempt := 1; ; attempt++ {
+		// 배치는 한 파티션의 메시지이므로 첫 메시지로 브레이커를 확인합니다.
+		if breaker != nil && breaker.acquire(ctx, items[0].raw, policy.BreakerOpenDuration) != nil {
+			return
+		}
+		failed, errs := c.handleBatch(ctx, eventType, items, policy.timeout(eventType))
+		// 일부만 실패했으면 의존 대상은 동작하는 것으로 보고 배치 전체가 실패한 경우만 실패로 셉니다.
+		var batchErr error
+		if len(failed) == len(items) {
+			batchErr = errs[items[0].raw.Offset]
+		}
+		recordBreaker(ctx, breaker, items[0].raw, batchErr, policy.BreakerThreshold)
+		for _, item := range items {
+			if _, ok := errs[item.raw.Offset]; !ok {
+				complete(item.raw)
+			}
+		}
+		if len(failed) == 0 {
+			return
+		}
+
+		// 세션이 끝나 실패한 메시지는 기록하지 않고 다음 소유자가 다시 처리하게 합니다.
+		if ctx.Err() != nil {
+			return
+		}
+
+		var retry []batchItem
+		var backoff time.Duration
+		for _, item := range failed {
+			err := errs[item.raw.Offset]
+			switch {
+			case events.IsSkip(err):
+				complete(item.raw)
+			case events.IsPermanent(err) || attempt >= policy.MaxAttempts:
+				// DLQ로 보내지 못했으면 세션이 끝난 것이므로 나머지 메시지도 기록하지 않습니다.
+				if c.fail(ctx, item.raw, err) != nil {
+					return
+				}
+				complete(item.raw)
+			default:
+				retry = append(retry, item)
+				backoff = max(backoff, retryBackoff(policy, attempt, err))
+			}
+		}
+		if len(retry) == 0 {
+			return
+		}
+
+		c.logger.Warn("retrying failed messages in batch",
+			"type", eventType,
+			"failed", len(retry),
+			"attempt", attempt,
+			"backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		items = retry
+	}
+}
+
+// handleBatch는 배치 핸들러를 호출하고 실패한 메시지와 오프셋별 오류를 반환합니다.
+// 핸들러가 *events.BatchError를 반환하면 해당 메시지만, 그 밖의 오류는 배치 전체가 실패한 것으로 봅니다.
+func (c *Consumer) handleBatch(sessionCtx context.Context, eventType string, items []batchItem, timeout time.Duration) ([]batchItem, map[int64]error) {
+	ctx := sessionCtx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(sessionCtx, timeout)
+		defer cancel()
+	}
+
+	msgs := make([]events.Message, len(items))
+	for i, item := range items {
+		msgs[i] = item.msg
+	}
+
+	err := c.router.HandleBatch(ctx, eventType, msgs)
+	if err == nil {
+		return nil, nil
+	}
+	if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w: %s batch after %s: %w", events.ErrHandlerTimeout, eventType, timeout, err)
+	}
+
+	errs := make(map[int64]error)
+	var batchErr *events.BatchError
+	if !errors.As(err, &batchErr) || ctx.Err() != nil {
+		for _, item := range items {
+			errs[item.raw.Offset] = err
+		}
+		return items, errs
+	}
+
+	// 범위를 벗어난 인덱스와 nil 오류는 실패로 보지 않습니다.
+	indexes := make([]int, 0, len(batchErr.Errors))
+	for i, itemErr := range batchErr.Errors {
+		if i >= 0 && i < len(items) && itemErr != nil {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+
+	failed := make([]batchItem, len(indexes))
+	for j, i := range indexes {
+		failed[j] = items[i]
+		errs[items[i].raw.Offset] = batchErr.Errors[i]
+	}
+	return failed, errs
+}
@@ -0,0 +1,187 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/events"
+	"github.com/hoo47/kafka_ex/internal/schema"
+	pkgevents "github.com/hoo47/kafka_ex/pkg/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordingBatchHandler는 받은 배치의 오프셋을 기록하고, 처음 받은 배치의 첫 메시지를 한 번 실패시킵니다.
+type recordingBatchHandler struct {
+	mu      sync.Mutex
+	batches [][]int64
+}
+
+func (h *recordingBatchHandler) EventType() string { return "AppInstallEvent" }
+
+func (h *recordingBatchHandler) HandleBatch(_ context.Context, msgs []events.Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	offsets := make([]int64, len(msgs))
+	for i, msg := range msgs {
+		offsets[i] = msg.Offset
+	}
+	h.batches = append(h.batches, offsets)
+	if len(h.batches) == 1 {
+		return &events.BatchError{Errors: map[int]error{0: errors.New("temporary failure")}}
+	}
+	return nil
+}
+
+func TestConsumer_BatchHandler(t *testing.T) {
+	consumer, template := newTestConsumer(t, &flakyHandler{}, HandlingPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		BatchSize:      2,
+		BatchMaxAge:    20 * time.Millisecond,
+	})
+	h := &recordingBatchHandler{}
+	consumer.router.RegisterBatchHandler(h)
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+	for i := 0; i < 3; i++ {
+		value, err := consumer.codec.Serialize("AppInstallEvent", &pkgevents.AppInstallEvent{AppId: fmt.Sprint(i)})
+		require.NoError(t, err)
+		claim.messages <- &sarama.ConsumerMessage{Topic: "app.events", Offset: int64(i), Value: value, Headers: template.Headers}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session := &fakeSession{ctx: ctx}
+	done := make(chan error, 1)
+	go func() { done <- consumer.ConsumeClaim(session, claim) }()
+
+	// 세 번째 메시지는 크기가 차지 않으므로 max_age가 지난 뒤 전달됩니다.
+	require.Eventually(t, func() bool {
+		session.mu.Lock()
+		defer session.mu.Unlock()
+		return len(session.marked) > 0 && session.marked[len(session.marked)-1] == 2
+	}, time.Second, 5*time.Millisecond)
+	close(claim.messages)
+	require.NoError(t, <-done)
+
+	assert.Equal(t, [][]int64{{0, 1}, {0}, {2}}, h.batches)
+	assert.Equal(t, []int64{1, 2}, session.marked)
+}
+
+// orderLog는 설치 이벤트는 워커로, 삭제 이벤트는 배치로 처리하면서 처리한 순서를 ChannelId로 기록합니다.
+type orderLog struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (l *orderLog) record(label string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order = append(l.order, label)
+}
+
+type slowInstallHandler struct{ log *orderLog }
+
+func (h slowInstallHandler) EventType() string { return "AppInstallEvent" }
+
+func (h slowInstallHandler) Handle(_ context.Context, msg proto.Message) error {
+	time.Sleep(20 * time.Millisecond)
+	h.log.record(msg.(*pkgevents.AppInstallEvent).ChannelId)
+	return nil
+}
+
+type uninstallBatchHandler struct{ log *orderLog }
+
+func (h uninstallBatchHandler) EventType() string { return "AppUninstallEvent" }
+
+func (h uninstallBatchHandler) HandleBatch(_ context.Context, msgs []events.Message) error {
+	for _, msg := range msgs {
+		h.log.record(msg.Event.(*pkgevents.AppUninstallEvent).ChannelId)
+	}
+	return nil
+}
+
+func TestConsumer_BatchKeepsKeyOrderWithWorkers(t *testing.T) {
+	log := &orderLog{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	registry := schema.NewMockSchemaRegistry()
+	registry.RegisterSchema("AppInstallEvent", 1, &pkgevents.AppInstallEvent{})
+	registry.RegisterSchema("AppUninstallEvent", 2, &pkgevents.AppUninstallEvent{})
+	codec := schema.NewCodec(registry)
+	router := events.NewEventRouter(logger)
+	router.RegisterHandler(slowInstallHandler{log: log}, &pkgevents.AppInstallEvent{})
+	router.RegisterBatchHandler(uninstallBatchHandler{log: log})
+	consumer := NewConsumer(router, logger, codec, WithHandlingPolicy(HandlingPolicy{
+		MaxAttempts: 1,
+		BatchSize:   10,
+		BatchMaxAge: time.Hour,
+	}))
+	consumer.defaultConcurrency = 4
+
+	// 같은 키의 설치, 삭제, 설치 이벤트입니다. 삭제 이벤트의 배치는 차지 않았지만, 앞선 설치 이벤트가
+	// 끝난 뒤, 뒤따르는 설치 이벤트보다 먼저 처리되어야 합니다.
+	messages := []struct {
+		eventType string
+		event     proto.Message
+	}{
+		{"AppInstallEvent", &pkgevents.AppInstallEvent{ChannelId: "0"}},
+		{"AppUninstallEvent", &pkgevents.AppUninstallEvent{ChannelId: "1"}},
+		{"AppInstallEvent", &pkgevents.AppInstallEvent{ChannelId: "2"}},
+	}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, len(messages))}
+	for i, m := range messages {
+		value, err := codec.Serialize(m.eventType, m.event)
+		require.NoError(t, err)
+		claim.messages <- &sarama.ConsumerMessage{
+			Topic:   "app.events",
+			Offset:  int64(i),
+			Key:     []byte("app-1"),
+			Value:   value,
+			Headers: []*sarama.RecordHeader{{Key: []byte("type"), Value: []byte(m.eventType)}},
+		}
+	}
+	close(claim.messages)
+
+	session := &fakeSession{ctx: context.Background()}
+	require.NoError(t, consumer.ConsumeClaim(session, claim))
+
+	assert.Equal(t, []string{"0", "1", "2"}, log.order)
+	assert.Equal(t, int64(2), session.marked[len(session.marked)-1])
+}
+
+// nilErrorBatchHandler는 두 번째 메시지에만 오류를 넣고 첫 메시지에는 nil을 넣은 BatchError를 반환합니다.
+type nilErrorBatchHandler struct{}
+
+func (nilErrorBatchHandler) EventType() string { return "AppInstallEvent" }
+
+func (nilErrorBatchHandler) HandleBatch(context.Context, []events.Message) error {
+	return &events.BatchError{Errors: map[int]error{0: nil, 1: events.Permanent(errors.New("invalid app"))}}
+}
+
+func TestConsumer_BatchErrorIgnoresNilEntries(t *testing.T) {
+	consumer, template := newTestConsumer(t, &flakyHandler{}, HandlingPolicy{MaxAttempts: 1, BatchSize: 2, BatchMaxAge: time.Hour})
+	consumer.router.RegisterBatchHandler(nilErrorBatchHandler{})
+
+	items := make([]batchItem, 2)
+	for i := range items {
+		raw := *template
+		raw.Offset = int64(i)
+		items[i] = batchItem{raw: &raw, msg: events.Message{Event: &pkgevents.AppInstallEvent{}, Offset: raw.Offset}}
+	}
+
+	failed, errs := consumer.handleBatch(context.Background(), "AppInstallEvent", items, 0)
+	require.Len(t, failed, 1)
+	assert.Equal(t, int64(1), failed[0].raw.Offset)
+	assert.NotContains(t, errs, int64(0))
+}
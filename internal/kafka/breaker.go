@@ -0,0 +1,311 @@
+package kafka
+
+import (
+	"context"
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/events"
+)
+
+// BreakerState는 서킷 브레이커의 상태입니다.
+type BreakerState int
+
+const (
+	// BreakerClosed는 메시지를 정상적으로 처리하는 상태입니다.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen은 연속 실패로 파티션을 멈추고 메시지를 처리하지 않는 상태입니다.
+	BreakerOpen
+	// BreakerHalfOpen은 메시지 하나로 의존 대상이 복구되었는지 시험하는 상태입니다.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerStatus는 관리 엔드포인트로 보여 주는 서킷 브레이커의 상태입니다.
+type BreakerStatus struct {
+	Name     string                  `json:"name"`
+	State    string                  `json:"state"`
+	Failures int                     `json:"failures"`
+	OpenedAt *time.Time              `json:"opened_at,omitempty"`
+	Paused   []events.TopicPartition `json:"paused,omitempty"`
+}
+
+// 브레이커 지표는 expvar로 /debug/vars에 노출됩니다.
+var (
+	breakerStates = expvar.NewMap("kafka_circuit_breaker_state")
+	breakerOpens  = expvar.NewMap("kafka_circuit_breaker_opens")
+)
+
+// PartitionPauser는 파티션에서 메시지를 가져오는 것을 멈추고 재개합니다. sarama.ConsumerGroup이 구현합니다.
+type PartitionPauser interface {
+	Pause(partitions map[string][]int32)
+	Resume(partitions map[string][]int32)
+}
+
+// circuitBreaker는 이벤트 타입 또는 의존 대상 하나의 연속 실패를 세고, 열려 있는 동안 실패한
+// 파티션을 멈춥니다. 열린 뒤 openDuration이 지나면 메시지 하나만 시험으로 처리하게 하고,
+// 성공하면 닫고 멈춘 파티션을 재개하며 실패하면 다시 엽니다.
+type circuitBreaker struct {
+	name   string
+	pauser PartitionPauser
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	paused   map[events.TopicPartition]bool
+	// changed는 상태가 바뀔 때 닫혀 기다리는 메시지를 깨웁니다.
+	changed chan struct{}
+}
+
+func newCircuitBreaker(name string, pauser PartitionPauser) *circuitBreaker {
+	breakerStates.Set(name, stateVar(BreakerClosed))
+	return &circuitBreaker{
+		name:    name,
+		pauser:  pauser,
+		paused:  make(map[events.TopicPartition]bool),
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire는 msg를 처리해도 될 때까지 기다립니다. 브레이커가 열려 있으면 msg의 파티션을 멈추고,
+// openDuration이 지나 시험 처리할 차례가 되거나 브레이커가 닫히면 nil을 반환합니다.
+func (b *circuitBreaker) acquire(ctx context.Context, msg *sarama.ConsumerMessage, openDuration time.Duration) error {
+	for {
+		b.mu.Lock()
+		wait, ok := b.tryAcquire(time.Now(), openDuration)
+		if !ok {
+			b.pause(events.TopicPartition{Topic: msg.Topic, Partition: msg.Partition})
+		}
+		changed := b.changed
+		b.mu.Unlock()
+		if ok {
+			return nil
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// tryAcquire는 지금 처리할 수 있는지와, 처리할 수 없으면 다시 확인할 때까지의 시간을 반환합니다.
+// 시간이 0이면 상태가 바뀔 때까지 기다립니다.
+func (b *circuitBreaker) tryAcquire(now time.Time, openDuration time.Duration) (time.Duration, bool) {
+	switch b.state {
+	case BreakerOpen:
+		if elapsed := now.Sub(b.openedAt); elapsed < openDuration {
+			return openDuration - elapsed, false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return 0, true
+	case BreakerHalfOpen:
+		if b.probing {
+			return 0, false
+		}
+		b.probing = true
+		return 0, true
+	default:
+		return 0, true
+	}
+}
+
+// success는 처리에 성공했음을 기록합니다. 브레이커가 닫혀 있지 않았으면 닫고 멈춘 파티션을 재개합니다.
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+	if b.state == BreakerClosed {
+		return
+	}
+	b.setState(BreakerClosed)
+	b.resume()
+}
+
+// failure는 msg 처리에 실패했음을 기록합니다. 연속 실패가 threshold에 도달하거나 시험 처리에 실패하면
+// 브레이커를 열고 msg의 파티션을 멈춥니다.
+func (b *circuitBreaker) failure(msg *sarama.ConsumerMessage, threshold int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.state == BreakerClosed && b.failures < threshold {
+		return
+	}
+	if b.state != BreakerOpen {
+		breakerOpens.Add(b.name, 1)
+	}
+	b.openedAt = time.Now()
+	b.setState(BreakerOpen)
+	b.pause(events.TopicPartition{Topic: msg.Topic, Partition: msg.Partition})
+}
+
+// release는 세션이 끝나 결과를 알 수 없는 시험 처리를 취소해 다른 메시지가 시험할 수 있게 합니다.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.probing {
+		b.probing = false
+		b.notify()
+	}
+}
+
+// revoke는 세션이 끝나 회수된 파티션을 멈춘 목록에서 뺍니다. sarama의 멈춤 상태는 세션의 파티션
+// 컨슈머에 있어 재할당되면 사라지므로, 다음 세션에서 브레이커가 열려 있으면 다시 멈춰야 합니다.
+func (b *circuitBreaker) revoke(partitions []events.TopicPartition) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, tp := range partitions {
+		delete(b.paused, tp)
+	}
+}
+
+func (b *circuitBreaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := BreakerStatus{
+		Name:     b.name,
+		State:    b.state.String(),
+		Failures: b.failures,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	for tp := range b.paused {
+		status.Paused = append(status.Paused, tp)
+	}
+	sortPartitions(status.Paused)
+	return status
+}
+
+func (b *circuitBreaker) setState(state BreakerState) {
+	b.state = state
+	breakerStates.Set(b.name, stateVar(state))
+	b.notify()
+}
+
+func (b *circuitBreaker) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func (b *circuitBreaker) pause(tp events.TopicPartition) {
+	if b.paused[tp] {
+		return
+	}
+	b.paused[tp] = true
+	if b.pauser != nil {
+		b.pauser.Pause(map[string][]int32{tp.Topic: {tp.Partition}})
+	}
+}
+
+func (b *circuitBreaker) resume() {
+	partitions := make(map[string][]int32)
+	for tp := range b.paused {
+		partitions[tp.Topic] = append(partitions[tp.Topic], tp.Partition)
+	}
+	b.paused = make(map[events.TopicPartition]bool)
+	if b.pauser != nil && len(partitions) > 0 {
+		b.pauser.Resume(partitions)
+	}
+}
+
+func stateVar(state BreakerState) *expvar.String {
+	v := new(expvar.String)
+	v.Set(state.String())
+	return v
+}
+
+// breakerFor는 eventType에 적용할 브레이커를 반환하며, 브레이커를 사용하지 않으면 nil을 반환합니다.
+// 의존 대상이 지정된 이벤트 타입은 의존 대상 이름의 브레이커를 공유합니다.
+func (c *Consumer) breakerFor(eventType string, policy *HandlingPolicy) *circuitBreaker {
+	if policy.BreakerThreshold <= 0 {
+		return nil
+	}
+	name := eventType
+	if dependency, ok := policy.BreakerDependencies[eventType]; ok {
+		name = dependency
+	}
+
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+	breaker, ok := c.breakers[name]
+	if !ok {
+		breaker = newCircuitBreaker(name, c.pauser)
+		c.breakers[name] = breaker
+	}
+	return breaker
+}
+
+// recordBreaker는 처리 결과를 브레이커에 기록합니다. 세션이 끝나 실패했거나 메시지 자체가 잘못되어
+// 실패한 경우는 의존 대상의 상태와 무관하므로 실패로 세지 않습니다.
+func recordBreaker(ctx context.Context, breaker *circuitBreaker, msg *sarama.ConsumerMessage, err error, threshold int) {
+	switch {
+	case breaker == nil:
+	case err == nil || events.IsSkip(err):
+		breaker.success()
+	case ctx.Err() != nil || events.IsPermanent(err):
+		breaker.release()
+	default:
+		breaker.failure(msg, threshold)
+	}
+}
+
+// revokeBreakers는 회수된 파티션을 모든 브레이커의 멈춘 목록에서 뺍니다.
+func (c *Consumer) revokeBreakers(partitions []events.TopicPartition) {
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+	for _, breaker := range c.breakers {
+		breaker.revoke(partitions)
+	}
+}
+
+// Breakers는 지금까지 사용한 서킷 브레이커의 상태를 이름 순서로 반환합니다.
+func (c *Consumer) Breakers() []BreakerStatus {
+	c.breakersMu.Lock()
+	breakers := make([]*circuitBreaker, 0, len(c.breakers))
+	for _, breaker := range c.breakers {
+		breakers = append(breakers, breaker)
+	}
+	c.breakersMu.Unlock()
+
+	statuses := make([]BreakerStatus, len(breakers))
+	for i, breaker := range breakers {
+		statuses[i] = breaker.status()
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
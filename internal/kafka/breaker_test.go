@@ -0,0 +1,128 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/events"
+)
+
+type fakePauser struct {
+	mu      sync.Mutex
+	paused  []map[string][]int32
+	resumed []map[string][]int32
+}
+
+func (p *fakePauser) Pause(partitions map[string][]int32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = append(p.paused, partitions)
+}
+
+func (p *fakePauser) Resume(partitions map[string][]int32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resumed = append(p.resumed, partitions)
+}
+
+func TestCircuitBreaker_OpensAndRecoversAfterProbe(t *testing.T) {
+	pauser := &fakePauser{}
+	breaker := newCircuitBreaker("billing", pauser)
+	msg := &sarama.ConsumerMessage{Topic: "app.events", Partition: 3}
+	openDuration := 20 * time.Millisecond
+
+	breaker.failure(msg, 2)
+	assert.Equal(t, BreakerClosed, breaker.state)
+	breaker.failure(msg, 2)
+	assert.Equal(t, BreakerOpen, breaker.state)
+	assert.Equal(t, []map[string][]int32{{"app.events": {3}}}, pauser.paused)
+
+	status := breaker.status()
+	assert.Equal(t, "open", status.State)
+	assert.Equal(t, []events.TopicPartition{{Topic: "app.events", Partition: 3}}, status.Paused)
+
+	// 열려 있는 동안은 기다리고, open_duration이 지나면 한 메시지만 시험으로 처리합니다.
+	start := time.Now()
+	require.NoError(t, breaker.acquire(context.Background(), msg, openDuration))
+	assert.GreaterOrEqual(t, time.Since(start), openDuration/2)
+	assert.Equal(t, BreakerHalfOpen, breaker.state)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, breaker.acquire(ctx, msg, openDuration), context.DeadlineExceeded)
+
+	breaker.success()
+	assert.Equal(t, BreakerClosed, breaker.state)
+	assert.Equal(t, []map[string][]int32{{"app.events": {3}}}, pauser.resumed)
+	assert.Empty(t, breaker.status().Paused)
+}
+
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	breaker := newCircuitBreaker("probe", nil)
+	msg := &sarama.ConsumerMessage{Topic: "app.events"}
+
+	breaker.failure(msg, 1)
+	require.NoError(t, breaker.acquire(context.Background(), msg, time.Millisecond))
+	assert.Equal(t, BreakerHalfOpen, breaker.state)
+
+	breaker.failure(msg, 1)
+	assert.Equal(t, BreakerOpen, breaker.state)
+}
+
+func TestConsumer_BreakerSharedByDependency(t *testing.T) {
+	h := &flakyHandler{failures: 10}
+	consumer, msg := newTestConsumer(t, h, HandlingPolicy{
+		MaxAttempts:         1,
+		BreakerThreshold:    2,
+		BreakerOpenDuration: time.Hour,
+		BreakerDependencies: map[string]string{"AppInstallEvent": "install-db"},
+	})
+
+	assert.Error(t, consumer.process(context.Background(), msg))
+	assert.Error(t, consumer.process(context.Background(), msg))
+
+	statuses := consumer.Breakers()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "install-db", statuses[0].Name)
+	assert.Equal(t, "open", statuses[0].State)
+
+	// 열려 있는 동안에는 핸들러를 호출하지 않고 기다립니다.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, consumer.process(ctx, msg), context.DeadlineExceeded)
+	assert.Equal(t, 2, h.calls)
+}
+
+func TestConsumer_BreakerPausesAgainInNewSession(t *testing.T) {
+	h := &flakyHandler{failures: 10}
+	consumer, msg := newTestConsumer(t, h, HandlingPolicy{
+		MaxAttempts:         1,
+		BreakerThreshold:    1,
+		BreakerOpenDuration: time.Hour,
+	})
+	pauser := &fakePauser{}
+	consumer.pauser = pauser
+	session := &fakeSession{ctx: context.Background(), claims: map[string][]int32{"app.events": {0}}}
+
+	require.NoError(t, consumer.Setup(session))
+	assert.Error(t, consumer.process(context.Background(), msg))
+	assert.Len(t, pauser.paused, 1)
+
+	// 리밸런스로 세션이 바뀌면 sarama의 멈춤 상태가 사라지므로, 브레이커가 열려 있는 동안
+	// 새 세션에서 메시지가 막히면 파티션을 다시 멈춥니다.
+	require.NoError(t, consumer.Cleanup(session))
+	assert.Empty(t, consumer.Breakers()[0].Paused)
+	require.NoError(t, consumer.Setup(session))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, consumer.process(ctx, msg), context.DeadlineExceeded)
+	assert.Equal(t, []map[string][]int32{{"app.events": {0}}, {"app.events": {0}}}, pauser.paused)
+	assert.Equal(t, []events.TopicPartition{{Topic: "app.events", Partition: 0}}, consumer.Breakers()[0].Paused)
+}
@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/config"
+)
+
+// NewClientConfig는 producer와 admin처럼 컨슈머가 아닌 클라이언트를 위해 kafka.version과
+// 보안 설정만 적용한 sarama.Config를 생성합니다.
+func NewClientConfig(cfg *config.Config) (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	if errs := applyClientConfig(saramaConfig, cfg); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return saramaConfig, nil
+}
+
+func applyClientConfig(saramaConfig *sarama.Config, cfg *config.Config) []error {
+	var errs []error
+	if cfg.Kafka.Version != "" {
+		version, err := sarama.ParseKafkaVersion(cfg.Kafka.Version)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("kafka.version: %w", err))
+		} else {
+			saramaConfig.Version = version
+		}
+	}
+	return append(errs, applySecurity(saramaConfig, cfg)...)
+}
+
+// NewConsumerConfig는 설정 파일의 kafka.consumer 항목을 sarama.Config로 변환합니다.
+// 비어 있는 항목은 기본값을 사용하며, 잘못된 항목은 모두 모아 하나의 오류로 반환합니다.
+func NewConsumerConfig(cfg *config.Config) (*sarama.Config, error) {
+	consumer := cfg.Kafka.Consumer
+	saramaConfig := sarama.NewConfig()
+	errs := applyClientConfig(saramaConfig, cfg)
+
+	if consumer.ClientID != "" {
+		saramaConfig.ClientID = consumer.ClientID
+	}
+
+	// 정적 멤버십은 인스턴스마다 달라야 하므로 ${HOSTNAME} 같은 환경 변수를 사용할 수 있습니다.
+	if instanceID := os.ExpandEnv(consumer.GroupInstanceID); instanceID != "" {
+		saramaConfig.Consumer.Group.InstanceId = instanceID
+		if cfg.Kafka.Version == "" {
+			saramaConfig.Version = sarama.V2_3_0_0
+		}
+	}
+
+	switch consumer.AutoOffsetReset {
+	case "", "oldest", "earliest":
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	case "newest", "latest":
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+	default:
+		errs = append(errs, fmt.Errorf("kafka.consumer.auto_offset_reset: unsupported value %q", consumer.AutoOffsetReset))
+	}
+
+	switch consumer.RebalanceStrategy {
+	case "", "roundrobin":
+		saramaConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
+	case "range":
+		saramaConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
+	case "sticky":
+		saramaConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+	case "cooperative-sticky":
+		errs = append(errs, errors.New("kafka.consumer.rebalance_strategy: cooperative-sticky is not supported by sarama, use sticky"))
+	default:
+		errs = append(errs, fmt.Errorf("kafka.consumer.rebalance_strategy: unsupported value %q", consumer.RebalanceStrategy))
+	}
+
+	if consumer.SessionTimeout > 0 {
+		saramaConfig.Consumer.Group.Session.Timeout = consumer.SessionTimeout
+	}
+	if consumer.HeartbeatInterval > 0 {
+		saramaConfig.Consumer.Group.Heartbeat.Interval = consumer.HeartbeatInterval
+	}
+	if saramaConfig.Consumer.Group.Heartbeat.Interval >= saramaConfig.Consumer.Group.Session.Timeout {
+		errs = append(errs, fmt.Errorf("kafka.consumer.heartbeat_interval: must be less than session_timeout (%s)",
+			saramaConfig.Consumer.Group.Session.Timeout))
+	}
+
+	switch consumer.IsolationLevel {
+	case "", "read_committed":
+		saramaConfig.Consumer.IsolationLevel = sarama.ReadCommitted
+	case "read_uncommitted":
+		saramaConfig.Consumer.IsolationLevel = sarama.ReadUncommitted
+	default:
+		errs = append(errs, fmt.Errorf("kafka.consumer.isolation_level: unsupported value %q", consumer.IsolationLevel))
+	}
+
+	fetch := consumer.Fetch
+	switch {
+	case fetch.MinBytes < 0:
+		errs = append(errs, errors.New("kafka.consumer.fetch.min_bytes: must not be negative"))
+	case fetch.MinBytes > 0:
+		saramaConfig.Consumer.Fetch.Min = fetch.MinBytes
+	}
+	switch {
+	case fetch.DefaultBytes < 0:
+		errs = append(errs, errors.New("kafka.consumer.fetch.default_bytes: must not be negative"))
+	case fetch.DefaultBytes > 0:
+		saramaConfig.Consumer.Fetch.Default = fetch.DefaultBytes
+	}
+	switch {
+	case fetch.MaxBytes < 0:
+		errs = append(errs, errors.New("kafka.consumer.fetch.max_bytes: must not be negative"))
+	case fetch.MaxBytes > 0 && fetch.MaxBytes < saramaConfig.Consumer.Fetch.Default:
+		errs = append(errs, errors.New("kafka.consumer.fetch.max_bytes: must not be less than default_bytes"))
+	default:
+		saramaConfig.Consumer.Fetch.Max = fetch.MaxBytes
+	}
+	if fetch.MaxWait > 0 {
+		saramaConfig.Consumer.MaxWaitTime = fetch.MaxWait
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// 위에서 확인하지 않는 조합(버전과 기능의 호환성 등)은 sarama가 검증합니다.
+	if err := saramaConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kafka consumer config: %w", err)
+	}
+	return saramaConfig, nil
+}
@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+)
+
+func TestNewConsumerConfig_Defaults(t *testing.T) {
+	saramaConfig, err := NewConsumerConfig(&config.Config{})
+	require.NoError(t, err)
+
+	assert.Equal(t, sarama.OffsetOldest, saramaConfig.Consumer.Offsets.Initial)
+	assert.Equal(t, sarama.RoundRobinBalanceStrategyName, saramaConfig.Consumer.Group.Rebalance.GroupStrategies[0].Name())
+	assert.Equal(t, sarama.ReadCommitted, saramaConfig.Consumer.IsolationLevel)
+	assert.Equal(t, 10*time.Second, saramaConfig.Consumer.Group.Session.Timeout)
+}
+
+func TestNewConsumerConfig_MapsFields(t *testing.T) {
+	t.Setenv("HOSTNAME", "consumer-0")
+
+	cfg := &config.Config{}
+	cfg.Kafka.Consumer.ClientID = "app-events-consumer"
+	cfg.Kafka.Consumer.GroupInstanceID = "${HOSTNAME}"
+	cfg.Kafka.Consumer.AutoOffsetReset = "newest"
+	cfg.Kafka.Consumer.RebalanceStrategy = "sticky"
+	cfg.Kafka.Consumer.SessionTimeout = 30 * time.Second
+	cfg.Kafka.Consumer.HeartbeatInterval = 5 * time.Second
+	cfg.Kafka.Consumer.IsolationLevel = "read_uncommitted"
+	cfg.Kafka.Consumer.Fetch.DefaultBytes = 4 << 20
+	cfg.Kafka.Consumer.Fetch.MaxWait = time.Second
+
+	saramaConfig, err := NewConsumerConfig(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "app-events-consumer", saramaConfig.ClientID)
+	assert.Equal(t, "consumer-0", saramaConfig.Consumer.Group.InstanceId)
+	assert.True(t, saramaConfig.Version.IsAtLeast(sarama.V2_3_0_0))
+	assert.Equal(t, sarama.OffsetNewest, saramaConfig.Consumer.Offsets.Initial)
+	assert.Equal(t, sarama.StickyBalanceStrategyName, saramaConfig.Consumer.Group.Rebalance.GroupStrategies[0].Name())
+	assert.Equal(t, 30*time.Second, saramaConfig.Consumer.Group.Session.Timeout)
+	assert.Equal(t, 5*time.Second, saramaConfig.Consumer.Group.Heartbeat.Interval)
+	assert.Equal(t, sarama.ReadUncommitted, saramaConfig.Consumer.IsolationLevel)
+	assert.Equal(t, int32(4<<20), saramaConfig.Consumer.Fetch.Default)
+	assert.Equal(t, time.Second, saramaConfig.Consumer.MaxWaitTime)
+}
+
+func TestNewConsumerConfig_ReportsAllInvalidFields(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kafka.Consumer.AutoOffsetReset = "beginning"
+	cfg.Kafka.Consumer.RebalanceStrategy = "cooperative-sticky"
+	cfg.Kafka.Consumer.HeartbeatInterval = time.Minute
+
+	_, err := NewConsumerConfig(cfg)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "kafka.consumer.auto_offset_reset")
+	assert.ErrorContains(t, err, "kafka.consumer.rebalance_strategy")
+	assert.ErrorContains(t, err, "kafka.consumer.heartbeat_interval")
+}
@@ -2,59 +2,387 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/hoo47/kafka_ex/internal/events"
 	"github.com/hoo47/kafka_ex/internal/schema"
+	"golang.org/x/time/rate"
 )
 
 type Consumer struct {
-	router *events.EventRouter
-	logger *slog.Logger
-	codec  *schema.Codec
+	router  *events.EventRouter
+	logger  *slog.Logger
+	codec   *schema.Codec
+	policy  atomic.Pointer[HandlingPolicy]
+	limiter *rate.Limiter
+
+	defaultConcurrency int
+	topicConcurrency   map[string]int
+
+	pauser     PartitionPauser
+	breakersMu sync.Mutex
+	breakers   map[string]*circuitBreaker
+
+	dlqProducer sarama.SyncProducer
+	dlqTopic    string
 }
 
-func NewConsumer(router *events.EventRouter, logger *slog.Logger, codec *schema.Codec) *Consumer {
-	return &Consumer{
-		router: router,
-		logger: logger,
-		codec:  codec,
+// ConsumerOption은 Consumer의 선택적인 동작을 설정합니다.
+type ConsumerOption func(*Consumer)
+
+// WithHandlingPolicy는 처음 적용할 처리 설정을 지정합니다.
+func WithHandlingPolicy(p HandlingPolicy) ConsumerOption {
+	return func(c *Consumer) {
+		c.SetPolicy(p)
 	}
 }
 
-func (c *Consumer) Setup(sarama.ConsumerGroupSession) error   { return nil }
-func (c *Consumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+// WithConcurrency는 파티션마다 동시에 처리할 메시지 수를 지정합니다. topics에 없는 토픽은
+// defaultWorkers를 사용하며, 1 이하이면 메시지를 하나씩 처리합니다.
+func WithConcurrency(defaultWorkers int, topics map[string]int) ConsumerOption {
+	return func(c *Consumer) {
+		c.defaultConcurrency = defaultWorkers
+		c.topicConcurrency = topics
+	}
+}
 
-func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		if err := c.handleMessage(context.Background(), msg); err != nil {
-			c.logger.Error("failed to handle message",
+// WithPauser는 서킷 브레이커가 열렸을 때 파티션을 멈추고 재개하는 데 사용할 컨슈머 그룹을 지정합니다.
+// 지정하지 않으면 브레이커가 열려 있는 동안 메시지를 처리하지 않고 기다리기만 합니다.
+func WithPauser(p PartitionPauser) ConsumerOption {
+	return func(c *Consumer) {
+		c.pauser = p
+	}
+}
+
+func NewConsumer(router *events.EventRouter, logger *slog.Logger, codec *schema.Codec, opts ...ConsumerOption) *Consumer {
+	c := &Consumer{
+		router:   router,
+		logger:   logger,
+		codec:    codec,
+		limiter:  rate.NewLimiter(rate.Inf, 0),
+		breakers: make(map[string]*circuitBreaker),
+	}
+	c.SetPolicy(HandlingPolicy{})
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SetPolicy는 처리 설정을 바꿉니다. 처리 중인 메시지에는 영향을 주지 않으며 다음 메시지부터 적용됩니다.
+func (c *Consumer) SetPolicy(p HandlingPolicy) {
+	if p.RateLimit > 0 {
+		c.limiter.SetLimit(rate.Limit(p.RateLimit))
+		c.limiter.SetBurst(max(p.Burst, 1))
+	} else {
+		c.limiter.SetLimit(rate.Inf)
+	}
+	c.policy.Store(&p)
+}
+
+// Setup은 메시지를 처리하기 전에 PartitionLifecycle을 구현한 핸들러에 할당받은 파티션을 알립니다.
+// 콜백이 실패하면 세션을 시작하지 않습니다.
+func (c *Consumer) Setup(session sarama.ConsumerGroupSession) error {
+	partitions := claimedPartitions(session)
+	for _, lifecycle := range c.router.PartitionLifecycles() {
+		if err := lifecycle.OnAssigned(session.Context(), partitions); err != nil {
+			return fmt.Errorf("failed to assign partitions to %T: %w", lifecycle, err)
+		}
+	}
+	return nil
+}
+
+// Cleanup은 모든 ConsumeClaim이 끝난 뒤 호출됩니다. 핸들러에 파티션 회수를 알린 다음,
+// 자동 커밋 주기를 기다리지 않고 표시한 오프셋을 바로 커밋합니다.
+func (c *Consumer) Cleanup(session sarama.ConsumerGroupSession) error {
+	// 세션 컨텍스트는 이미 취소되었으므로 취소되지 않는 컨텍스트로 콜백을 호출합니다.
+	ctx := context.WithoutCancel(session.Context())
+	partitions := claimedPartitions(session)
+	c.revokeBreakers(partitions)
+	for _, lifecycle := range c.router.PartitionLifecycles() {
+		if err := lifecycle.OnRevoked(ctx, partitions); err != nil {
+			c.logger.Error("failed to revoke partitions",
 				"error", err,
+				"handler", fmt.Sprintf("%T", lifecycle))
+		}
+	}
+	session.Commit()
+	return nil
+}
+
+func claimedPartitions(session sarama.ConsumerGroupSession) []events.TopicPartition {
+	var partitions []events.TopicPartition
+	for topic, ids := range session.Claims() {
+		for _, id := range ids {
+			partitions = append(partitions, events.TopicPartition{Topic: topic, Partition: id})
+		}
+	}
+	sortPartitions(partitions)
+	return partitions
+}
+
+func sortPartitions(partitions []events.TopicPartition) {
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].Topic != partitions[j].Topic {
+			return partitions[i].Topic < partitions[j].Topic
+		}
+		return partitions[i].Partition < partitions[j].Partition
+	})
+}
+
+// ConsumeClaim은 파티션의 메시지를 처리합니다. 워커나 배치로 처리하면 메시지가 끝나는 순서가 받은
+// 순서와 다를 수 있으므로, 오프셋은 그보다 앞선 메시지가 모두 끝난 경우에만 표시합니다.
+// 따라서 중간에 종료되어도 처리하지 않은 메시지의 오프셋은 커밋되지 않습니다.
+// 같은 키의 메시지는 배치로 처리하는 이벤트 타입과 섞여 있어도 받은 순서대로 처리합니다.
+// 재시도 끝에 실패한 메시지는 로그를 남기고 DLQ로 보낸 뒤 끝난 것으로 봅니다.
+func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	tracker := &offsetTracker{}
+	complete := func(msg *sarama.ConsumerMessage) {
+		tracker.complete(msg, func(watermark *sarama.ConsumerMessage) {
+			session.MarkMessage(watermark, "")
+		})
+	}
+
+	dispatch := func(msg *sarama.ConsumerMessage) bool {
+		c.handleAndComplete(ctx, msg, complete)
+		return true
+	}
+	wait := func() {}
+	if workers := c.concurrency(claim.Topic()); workers > 1 {
+		var stop func()
+		dispatch, wait, stop = c.startWorkers(ctx, workers, complete)
+		defer stop()
+	}
+
+	// 배치는 이 고루틴에서 바로 처리하므로, 워커에 넘긴 메시지가 모두 끝난 뒤 처리해야 앞서 받은
+	// 같은 키의 메시지보다 먼저 처리되지 않습니다.
+	batches := &batcher{}
+	flush := func(eventType string, items []batchItem) {
+		wait()
+		c.flushBatch(ctx, eventType, items, complete)
+	}
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		var flushC <-chan time.Time
+		if deadline, ok := batches.nextDeadline(); ok {
+			resetTimer(timer, time.Until(deadline))
+			flushC = timer.C
+		}
+
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			// 리밸런싱이나 종료로 세션이 끝나면 버퍼에 남은 메시지는 처리하지 않고 다음 소유자에게 넘깁니다.
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil
+			}
+			tracker.add(msg)
+
+			eventType := getHeaderValue(msg.Headers, "type")
+			batched := c.router.HasBatchHandler(eventType) && !c.policy.Load().Disabled[eventType]
+
+			// 같은 키의 앞선 메시지가 다른 배치에 남아 있으면 먼저 처리해 키별 순서를 지킵니다.
+			if len(msg.Key) > 0 {
+				except := ""
+				if batched {
+					except = eventType
+				}
+				if pendingType, items, ok := batches.take(msg.Key, except); ok {
+					flush(pendingType, items)
+				}
+			}
+
+			if batched {
+				c.addToBatch(ctx, batches, eventType, msg, complete, flush)
+				continue
+			}
+			if !dispatch(msg) {
+				return nil
+			}
+		case <-flushC:
+			for eventType, items := range batches.due(time.Now()) {
+				flush(eventType, items)
+			}
+		}
+	}
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+// handleAndComplete는 메시지를 처리한 뒤 끝난 것으로 기록합니다. 처리에 실패한 메시지는 DLQ로 보내며,
+// 세션이 끝나 실패했거나 DLQ로 보내지 못한 메시지는 기록하지 않고 다음 소유자가 다시 처리하게 합니다.
+// 파티션을 멈춰야 하는 메시지는 세션이 끝날 때까지 기다린 뒤 기록하지 않고 반환합니다.
+func (c *Consumer) handleAndComplete(ctx context.Context, msg *sarama.ConsumerMessage, complete func(*sarama.ConsumerMessage)) {
+	if err := c.process(ctx, msg); err != nil {
+		if errors.Is(err, errHaltPartition) {
+			c.halt(ctx, msg, err)
+			return
+		}
+		if ctx.Err() != nil {
+			c.logFailure(msg, err)
+			return
+		}
+		if c.fail(ctx, msg, err) != nil {
+			return
+		}
+	}
+	complete(msg)
+}
+
+// fail은 처리에 실패한 메시지의 로그를 남기고 DLQ로 보냅니다. DLQ로 보내지 못하면 오류를 반환합니다.
+func (c *Consumer) fail(ctx context.Context, msg *sarama.ConsumerMessage, err error) error {
+	c.logFailure(msg, err)
+	return c.deadLetter(ctx, msg, err)
+}
+
+func (c *Consumer) logFailure(msg *sarama.ConsumerMessage, err error) {
+	c.logger.Error("failed to handle message",
+		"error", err,
+		"timeout", errors.Is(err, events.ErrHandlerTimeout),
+		"permanent", events.IsPermanent(err),
+		"topic", msg.Topic,
+		"partition", msg.Partition,
+		"offset", msg.Offset)
+}
+
+// process는 처리 설정에 따라 메시지를 처리하고, 실패하면 MaxAttempts까지 재시도합니다.
+// 이벤트 타입의 서킷 브레이커가 열려 있으면 시도하기 전에 브레이커가 허용할 때까지 기다립니다.
+// 핸들러가 events.Skip을 반환하면 성공으로, events.Permanent로 표시한 실패는 재시도하지 않으며,
+// events.RetryAfter로 표시한 실패는 재시도 설정의 대기 시간 대신 지정한 시간만큼 기다립니다.
+// 핸들러가 없는 이벤트 타입의 메시지는 기본 핸들러가 없으면 UnknownType 설정에 따라 처리합니다.
+func (c *Consumer) process(sessionCtx context.Context, msg *sarama.ConsumerMessage) error {
+	policy := c.policy.Load()
+
+	eventType := getHeaderValue(msg.Headers, "type")
+	if policy.Disabled[eventType] {
+		c.logger.Debug("skipping disabled event type",
+			"type", eventType,
+			"topic", msg.Topic,
+			"partition", msg.Partition,
+			"offset", msg.Offset)
+		return nil
+	}
+	if eventType != "" && !c.router.HasHandler(eventType) && !c.router.HasDefaultHandler() {
+		return c.unknownEventType(msg, eventType, policy.UnknownType)
+	}
+
+	breaker := c.breakerFor(eventType, policy)
+	for attempt := 1; ; attempt++ {
+		if breaker != nil {
+			if err := breaker.acquire(sessionCtx, msg, policy.BreakerOpenDuration); err != nil {
+				return err
+			}
+		}
+		err := c.handleWithTimeout(sessionCtx, msg, eventType, policy.timeout(eventType))
+		recordBreaker(sessionCtx, breaker, msg, err, policy.BreakerThreshold)
+		if events.IsSkip(err) {
+			c.logger.Debug("skipping message",
+				"reason", err,
 				"topic", msg.Topic,
 				"partition", msg.Partition,
 				"offset", msg.Offset)
-			continue
+			return nil
+		}
+		if err == nil || events.IsPermanent(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		backoff := retryBackoff(policy, attempt, err)
+		c.logger.Warn("retrying message",
+			"error", err,
+			"attempt", attempt,
+			"backoff", backoff,
+			"topic", msg.Topic,
+			"partition", msg.Partition,
+			"offset", msg.Offset)
+
+		select {
+		case <-sessionCtx.Done():
+			return err
+		case <-time.After(backoff):
 		}
-		session.MarkMessage(msg, "")
 	}
-	return nil
+}
+
+// handleWithTimeout은 세션 컨텍스트에서 파생한 컨텍스트로 핸들러를 호출합니다. 파티션이 회수되거나
+// 종료할 때 핸들러의 ctx도 취소되며, 제한 시간을 넘기면 events.ErrHandlerTimeout으로 감싼 오류를 반환합니다.
+func (c *Consumer) handleWithTimeout(sessionCtx context.Context, msg *sarama.ConsumerMessage, eventType string, timeout time.Duration) error {
+	ctx := sessionCtx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(sessionCtx, timeout)
+		defer cancel()
+	}
+
+	err := c.handleMessage(ctx, msg)
+	if err != nil && timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %s after %s: %w", events.ErrHandlerTimeout, eventType, timeout, err)
+	}
+	return err
 }
 
 func (c *Consumer) handleMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
 	eventType := getHeaderValue(msg.Headers, "type")
 	if eventType == "" {
-		return fmt.Errorf("message missing type header")
+		return events.Permanent(fmt.Errorf("message missing type header"))
+	}
+	// 핸들러가 없는 이벤트 타입은 역직렬화하지 않고 기본 핸들러에 전달합니다.
+	if !c.router.HasHandler(eventType) && c.router.HasDefaultHandler() {
+		return c.router.HandleRaw(ctx, rawMessage(msg, eventType))
 	}
 
 	// Schema Registry 형식으로 역직렬화
 	event, err := c.codec.Deserialize(msg.Value, eventType)
 	if err != nil {
-		return fmt.Errorf("failed to deserialize message: %w", err)
+		return deserializeError(err)
 	}
 
-	return c.router.HandleMessage(ctx, eventType, event)
+	return c.router.HandleMessage(ctx, eventType, event, events.Metadata{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Timestamp: msg.Timestamp,
+	})
+}
+
+// deserializeError는 역직렬화 실패를 감쌉니다. 메시지 자체가 잘못된 경우는 재시도해도 성공하지 않으므로
+// 재시도하지 않을 실패로 표시하고, Schema Registry 조회 실패처럼 일시적일 수 있는 경우는 그대로 둡니다.
+func deserializeError(err error) error {
+	err = fmt.Errorf("failed to deserialize message: %w", err)
+	if errors.Is(err, schema.ErrMalformedMessage) {
+		return events.Permanent(err)
+	}
+	return err
+}
+
+// retryBackoff는 attempt번째 실패 후 재시도하기 전의 대기 시간을 반환합니다.
+func retryBackoff(policy *HandlingPolicy, attempt int, err error) time.Duration {
+	var retryAfter *events.RetryAfterError
+	if errors.As(err, &retryAfter) {
+		return retryAfter.Delay
+	}
+	return policy.backoff(attempt)
 }
 
 func getHeaderValue(headers []*sarama.RecordHeader, key string) string {
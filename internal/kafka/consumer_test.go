@@ -0,0 +1,331 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/hoo47/kafka_ex/internal/events"
+	"github.com/hoo47/kafka_ex/internal/schema"
+	pkgevents "github.com/hoo47/kafka_ex/pkg/events"
+)
+
+// flakyHandler는 처음 failures번 호출될 때 실패합니다.
+type flakyHandler struct {
+	failures int
+	calls    int
+}
+
+func (h *flakyHandler) EventType() string { return "AppInstallEvent" }
+
+func (h *flakyHandler) Handle(context.Context, proto.Message) error {
+	h.calls++
+	if h.calls <= h.failures {
+		return errors.New("temporary failure")
+	}
+	return nil
+}
+
+func newTestConsumer(t *testing.T, h events.EventHandler, policy HandlingPolicy) (*Consumer, *sarama.ConsumerMessage) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	registry := schema.NewMockSchemaRegistry()
+	registry.RegisterSchema("AppInstallEvent", 1, &pkgevents.AppInstallEvent{})
+	codec := schema.NewCodec(registry)
+
+	router := events.NewEventRouter(logger)
+	router.RegisterHandler(h, &pkgevents.AppInstallEvent{})
+
+	value, err := codec.Serialize("AppInstallEvent", &pkgevents.AppInstallEvent{AppId: "app-1"})
+	require.NoError(t, err)
+	msg := &sarama.ConsumerMessage{
+		Topic:   "app.events",
+		Value:   value,
+		Headers: []*sarama.RecordHeader{{Key: []byte("type"), Value: []byte("AppInstallEvent")}},
+	}
+
+	return NewConsumer(router, logger, codec, WithHandlingPolicy(policy)), msg
+}
+
+func TestConsumer_RetriesUpToMaxAttempts(t *testing.T) {
+	h := &flakyHandler{failures: 2}
+	consumer, msg := newTestConsumer(t, h, HandlingPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	require.NoError(t, consumer.process(context.Background(), msg))
+	assert.Equal(t, 3, h.calls)
+
+	h = &flakyHandler{failures: 5}
+	consumer, msg = newTestConsumer(t, h, HandlingPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+	assert.Error(t, consumer.process(context.Background(), msg))
+	assert.Equal(t, 2, h.calls)
+}
+
+func TestConsumer_SetPolicyDisablesEventType(t *testing.T) {
+	h := &flakyHandler{}
+	consumer, msg := newTestConsumer(t, h, HandlingPolicy{MaxAttempts: 1})
+
+	consumer.SetPolicy(HandlingPolicy{MaxAttempts: 1, Disabled: map[string]bool{"AppInstallEvent": true}})
+	require.NoError(t, consumer.process(context.Background(), msg))
+	assert.Equal(t, 0, h.calls)
+
+	consumer.SetPolicy(HandlingPolicy{MaxAttempts: 1})
+	require.NoError(t, consumer.process(context.Background(), msg))
+	assert.Equal(t, 1, h.calls)
+}
+
+// blockingHandler는 ctx가 끝날 때까지 기다립니다.
+type blockingHandler struct{}
+
+func (blockingHandler) EventType() string { return "AppInstallEvent" }
+
+func (blockingHandler) Handle(ctx context.Context, _ proto.Message) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestConsumer_HandlerTimeout(t *testing.T) {
+	consumer, msg := newTestConsumer(t, blockingHandler{}, HandlingPolicy{
+		MaxAttempts: 1,
+		Timeout:     time.Hour,
+		Timeouts:    map[string]time.Duration{"AppInstallEvent": 10 * time.Millisecond},
+	})
+
+	err := consumer.process(context.Background(), msg)
+	assert.ErrorIs(t, err, events.ErrHandlerTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestConsumer_HandlerSeesSessionCancellation(t *testing.T) {
+	consumer, msg := newTestConsumer(t, blockingHandler{}, HandlingPolicy{MaxAttempts: 3, Timeout: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	err := consumer.process(ctx, msg)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, events.ErrHandlerTimeout)
+}
+
+// lifecycleHandler는 할당받거나 회수된 파티션을 기록합니다.
+type lifecycleHandler struct {
+	flakyHandler
+	assigned     []events.TopicPartition
+	revoked      []events.TopicPartition
+	revokeCtxErr error
+}
+
+func (h *lifecycleHandler) OnAssigned(_ context.Context, partitions []events.TopicPartition) error {
+	h.assigned = partitions
+	return nil
+}
+
+func (h *lifecycleHandler) OnRevoked(ctx context.Context, partitions []events.TopicPartition) error {
+	h.revoked = partitions
+	h.revokeCtxErr = ctx.Err()
+	return nil
+}
+
+func TestConsumer_NotifiesPartitionLifecycle(t *testing.T) {
+	h := &lifecycleHandler{}
+	consumer, _ := newTestConsumer(t, h, HandlingPolicy{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &fakeSession{ctx: ctx, claims: map[string][]int32{
+		"app.events":   {2, 0},
+		"audit.events": {1},
+	}}
+	want := []events.TopicPartition{
+		{Topic: "app.events", Partition: 0},
+		{Topic: "app.events", Partition: 2},
+		{Topic: "audit.events", Partition: 1},
+	}
+
+	require.NoError(t, consumer.Setup(session))
+	assert.Equal(t, want, h.assigned)
+	assert.Nil(t, h.revoked)
+
+	// sarama는 세션 컨텍스트를 취소한 뒤 Cleanup을 호출합니다.
+	cancel()
+	require.NoError(t, consumer.Cleanup(session))
+	assert.Equal(t, want, h.revoked)
+	assert.NoError(t, h.revokeCtxErr)
+}
+
+// errorHandler는 항상 err를 반환합니다.
+type errorHandler struct {
+	err   error
+	calls int
+}
+
+func (h *errorHandler) EventType() string { return "AppInstallEvent" }
+
+func (h *errorHandler) Handle(context.Context, proto.Message) error {
+	h.calls++
+	return h.err
+}
+
+func TestConsumer_ClassifiesHandlerErrors(t *testing.T) {
+	policy := HandlingPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}
+
+	h := &errorHandler{err: events.Permanent(errors.New("invalid channel"))}
+	consumer, msg := newTestConsumer(t, h, policy)
+	err := consumer.process(context.Background(), msg)
+	assert.True(t, events.IsPermanent(err))
+	assert.Equal(t, 1, h.calls)
+
+	h = &errorHandler{err: events.Skip("already installed")}
+	consumer, msg = newTestConsumer(t, h, policy)
+	assert.NoError(t, consumer.process(context.Background(), msg))
+	assert.Equal(t, 1, h.calls)
+
+	// RetryAfter의 대기 시간이 재시도 설정의 대기 시간보다 우선합니다.
+	h = &errorHandler{err: events.RetryAfter(time.Millisecond, errors.New("rate limited"))}
+	consumer, msg = newTestConsumer(t, h, policy)
+	assert.Error(t, consumer.process(context.Background(), msg))
+	assert.Equal(t, 3, h.calls)
+}
+
+func TestConsumer_SendsFailedMessagesToDLQ(t *testing.T) {
+	h := &errorHandler{err: errors.New("db timeout")}
+	consumer, msg := newTestConsumer(t, h, HandlingPolicy{MaxAttempts: 1})
+	producer := mocks.NewSyncProducer(t, nil)
+	WithDeadLetterQueue(producer, "app.events.dlq")(consumer)
+
+	var sent []*sarama.ProducerMessage
+	record := func(pm *sarama.ProducerMessage) error {
+		sent = append(sent, pm)
+		return nil
+	}
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(record)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(record)
+
+	completed := 0
+	complete := func(*sarama.ConsumerMessage) { completed++ }
+	consumer.handleAndComplete(context.Background(), msg, complete)
+
+	// 잘못된 형식의 메시지는 재시도하지 않을 실패로 분류됩니다.
+	malformed := *msg
+	malformed.Value = []byte{0x1, 0x0, 0x0, 0x0, 0x1}
+	consumer.handleAndComplete(context.Background(), &malformed, complete)
+
+	require.NoError(t, producer.Close())
+	assert.Equal(t, 2, completed)
+	assert.Equal(t, 1, h.calls)
+	require.Len(t, sent, 2)
+	assert.Equal(t, "app.events.dlq", sent[0].Topic)
+	assert.Equal(t, DLQReasonExhausted, dlqHeader(sent[0], HeaderDLQReason))
+	assert.Equal(t, "app.events", dlqHeader(sent[0], HeaderDLQTopic))
+	assert.Equal(t, "AppInstallEvent", dlqHeader(sent[0], "type"))
+	assert.Equal(t, DLQReasonPermanent, dlqHeader(sent[1], HeaderDLQReason))
+	assert.Contains(t, dlqHeader(sent[1], HeaderDLQError), "invalid magic byte")
+}
+
+func TestConsumer_DoesNotCompleteWhenDLQSendFails(t *testing.T) {
+	h := &errorHandler{err: errors.New("db timeout")}
+	consumer, template := newTestConsumer(t, h, HandlingPolicy{MaxAttempts: 1})
+	producer := mocks.NewSyncProducer(t, nil)
+	defer producer.Close()
+	producer.ExpectSendMessageAndFail(sarama.ErrNotEnoughReplicas)
+	WithDeadLetterQueue(producer, "app.events.dlq")(consumer)
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	for offset := int64(0); offset < 2; offset++ {
+		msg := *template
+		msg.Offset = offset
+		claim.messages <- &msg
+	}
+	close(claim.messages)
+
+	// DLQ로 보내지 못한 메시지는 세션이 끝날 때까지 다시 보내며, 오프셋은 앞으로 가지 않습니다.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	session := &fakeSession{ctx: ctx}
+	require.NoError(t, consumer.ConsumeClaim(session, claim))
+
+	assert.Equal(t, 1, h.calls)
+	assert.Empty(t, session.marked)
+}
+
+func dlqHeader(pm *sarama.ProducerMessage, key string) string {
+	for _, header := range pm.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// rawHandler는 기본 핸들러로 받은 메시지를 기록합니다.
+type rawHandler struct {
+	received []events.RawMessage
+}
+
+func (h *rawHandler) HandleRaw(_ context.Context, msg events.RawMessage) error {
+	h.received = append(h.received, msg)
+	return nil
+}
+
+func TestConsumer_UnknownEventTypePolicy(t *testing.T) {
+	unknown := func(msg *sarama.ConsumerMessage) *sarama.ConsumerMessage {
+		other := *msg
+		other.Headers = []*sarama.RecordHeader{{Key: []byte("type"), Value: []byte("BillingEvent")}}
+		return &other
+	}
+
+	h := &errorHandler{}
+	consumer, msg := newTestConsumer(t, h, HandlingPolicy{UnknownType: UnknownTypeMetric})
+	before := expvarInt(unknownEventTypes.Get("BillingEvent"))
+	assert.NoError(t, consumer.process(context.Background(), unknown(msg)))
+	assert.Equal(t, before+1, expvarInt(unknownEventTypes.Get("BillingEvent")))
+
+	consumer.SetPolicy(HandlingPolicy{UnknownType: UnknownTypeDLQ})
+	err := consumer.process(context.Background(), unknown(msg))
+	assert.True(t, events.IsPermanent(err))
+	assert.ErrorIs(t, err, events.ErrNoHandler)
+
+	consumer.SetPolicy(HandlingPolicy{UnknownType: UnknownTypeHalt})
+	assert.ErrorIs(t, consumer.process(context.Background(), unknown(msg)), errHaltPartition)
+	assert.Equal(t, 0, h.calls)
+
+	// 기본 핸들러가 있으면 처리 방식과 관계없이 원본 바이트를 전달합니다.
+	raw := &rawHandler{}
+	consumer.router.SetDefaultHandler(raw)
+	require.NoError(t, consumer.process(context.Background(), unknown(msg)))
+	require.Len(t, raw.received, 1)
+	assert.Equal(t, "BillingEvent", raw.received[0].Type)
+	assert.Equal(t, msg.Value, raw.received[0].Value)
+	assert.Equal(t, []byte("BillingEvent"), raw.received[0].Headers["type"])
+}
+
+func TestConsumer_HaltDoesNotCompleteMessage(t *testing.T) {
+	consumer, msg := newTestConsumer(t, &errorHandler{}, HandlingPolicy{UnknownType: UnknownTypeHalt})
+	pauser := &fakePauser{}
+	WithPauser(pauser)(consumer)
+	msg.Headers = []*sarama.RecordHeader{{Key: []byte("type"), Value: []byte("BillingEvent")}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	completed := false
+	consumer.handleAndComplete(ctx, msg, func(*sarama.ConsumerMessage) { completed = true })
+
+	assert.False(t, completed)
+	assert.Equal(t, []map[string][]int32{{"app.events": {0}}}, pauser.paused)
+}
+
+func expvarInt(v expvar.Var) int64 {
+	if v == nil {
+		return 0
+	}
+	return v.(*expvar.Int).Value()
+}
@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/events"
+)
+
+// DLQ로 보낸 메시지에 추가하는 헤더입니다. 원본 헤더(type 등)는 그대로 유지합니다.
+const (
+	HeaderDLQError     = "dlq.error"
+	HeaderDLQReason    = "dlq.reason"
+	HeaderDLQTopic     = "dlq.original.topic"
+	HeaderDLQPartition = "dlq.original.partition"
+	HeaderDLQOffset    = "dlq.original.offset"
+	HeaderDLQFailedAt  = "dlq.failed_at"
+)
+
+// dlq.reason 헤더의 값입니다.
+const (
+	// DLQReasonPermanent는 재시도하지 않을 실패로 분류되어 바로 보낸 메시지입니다.
+	DLQReasonPermanent = "permanent"
+	// DLQReasonExhausted는 재시도 횟수를 모두 쓴 메시지입니다.
+	DLQReasonExhausted = "exhausted"
+)
+
+// WithDeadLetterQueue는 처리에 실패한 메시지를 보낼 DLQ 토픽과 producer를 지정합니다.
+// 지정하지 않으면 실패한 메시지는 로그만 남깁니다.
+func WithDeadLetterQueue(producer sarama.SyncProducer, topic string) ConsumerOption {
+	return func(c *Consumer) {
+		c.dlqProducer = producer
+		c.dlqTopic = topic
+	}
+}
+
+// minDeadLetterBackoff는 DLQ로 다시 보내기 전 최소 대기 시간입니다. 재시도 설정의 대기 시간이 0이어도
+// 브로커에 계속 요청하지 않도록 합니다.
+const minDeadLetterBackoff = time.Second
+
+// deadLetter는 실패한 메시지를 원본 키와 값 그대로 DLQ로 보냅니다. 보내지 못하면 재시도 설정의 대기
+// 시간만큼 기다리며 세션이 끝날 때까지 다시 보내고, 끝내 보내지 못하면 오류를 반환합니다.
+// 오류를 반환하면 메시지를 끝난 것으로 기록하지 않아야 다음 소유자가 다시 처리해 메시지를 잃지 않습니다.
+func (c *Consumer) deadLetter(ctx context.Context, msg *sarama.ConsumerMessage, err error) error {
+	if c.dlqProducer == nil {
+		return nil
+	}
+	pm := deadLetterMessage(c.dlqTopic, msg, err, time.Now())
+	for attempt := 1; ; attempt++ {
+		_, _, sendErr := c.dlqProducer.SendMessage(pm)
+		if sendErr == nil {
+			return nil
+		}
+
+		backoff := max(c.policy.Load().backoff(attempt), minDeadLetterBackoff)
+		c.logger.Error("failed to send message to DLQ",
+			"error", sendErr,
+			"attempt", attempt,
+			"backoff", backoff,
+			"dlq", c.dlqTopic,
+			"topic", msg.Topic,
+			"partition", msg.Partition,
+			"offset", msg.Offset)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to send message to DLQ: %w", sendErr)
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func deadLetterMessage(topic string, msg *sarama.ConsumerMessage, err error, failedAt time.Time) *sarama.ProducerMessage {
+	reason := DLQReasonExhausted
+	if events.IsPermanent(err) {
+		reason = DLQReasonPermanent
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+6)
+	for _, header := range msg.Headers {
+		headers = append(headers, *header)
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(HeaderDLQError), Value: []byte(err.Error())},
+		sarama.RecordHeader{Key: []byte(HeaderDLQReason), Value: []byte(reason)},
+		sarama.RecordHeader{Key: []byte(HeaderDLQTopic), Value: []byte(msg.Topic)},
+		sarama.RecordHeader{Key: []byte(HeaderDLQPartition), Value: []byte(strconv.Itoa(int(msg.Partition)))},
+		sarama.RecordHeader{Key: []byte(HeaderDLQOffset), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		sarama.RecordHeader{Key: []byte(HeaderDLQFailedAt), Value: []byte(failedAt.UTC().Format(time.RFC3339))},
+	)
+
+	pm := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		pm.Key = sarama.ByteEncoder(msg.Key)
+	}
+	return pm
+}
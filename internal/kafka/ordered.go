@@ -0,0 +1,107 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// workerQueueSize는 워커마다 대기시킬 수 있는 메시지 수입니다.
+const workerQueueSize = 16
+
+func (c *Consumer) concurrency(topic string) int {
+	if workers, ok := c.topicConcurrency[topic]; ok {
+		return workers
+	}
+	return c.defaultConcurrency
+}
+
+// startWorkers는 한 파티션의 메시지를 나눠 처리할 workers개의 워커를 시작합니다.
+// 같은 키의 메시지는 항상 같은 워커에서 받은 순서대로 처리되므로 키별 순서가 유지됩니다.
+// 키가 없는 메시지는 순서를 보장할 필요가 없으므로 오프셋으로 나눕니다.
+//
+// dispatch는 세션이 끝나 메시지를 넘기지 못하면 false를 반환하며, wait는 넘긴 메시지가 모두 끝날 때까지,
+// stop은 워커가 모두 끝날 때까지 기다립니다. dispatch와 wait는 한 고루틴에서만 호출해야 합니다.
+func (c *Consumer) startWorkers(ctx context.Context, workers int, complete func(*sarama.ConsumerMessage)) (dispatch func(*sarama.ConsumerMessage) bool, wait, stop func()) {
+	var wg, inflight sync.WaitGroup
+	queues := make([]chan *sarama.ConsumerMessage, workers)
+	for i := range queues {
+		queues[i] = make(chan *sarama.ConsumerMessage, workerQueueSize)
+		wg.Add(1)
+		go func(queue <-chan *sarama.ConsumerMessage) {
+			defer wg.Done()
+			for msg := range queue {
+				// 세션이 끝난 뒤 대기 중이던 메시지는 처리하지 않고 다음 소유자에게 넘깁니다.
+				if ctx.Err() == nil {
+					c.handleAndComplete(ctx, msg, complete)
+				}
+				inflight.Done()
+			}
+		}(queues[i])
+	}
+
+	dispatch = func(msg *sarama.ConsumerMessage) bool {
+		inflight.Add(1)
+		select {
+		case queues[workerFor(msg, workers)] <- msg:
+			return true
+		case <-ctx.Done():
+			inflight.Done()
+			return false
+		}
+	}
+	stop = func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}
+	return dispatch, inflight.Wait, stop
+}
+
+func workerFor(msg *sarama.ConsumerMessage, workers int) int {
+	if len(msg.Key) == 0 {
+		return int(msg.Offset % int64(workers))
+	}
+	h := fnv.New32a()
+	h.Write(msg.Key)
+	return int(h.Sum32() % uint32(workers))
+}
+
+// offsetTracker는 한 파티션에서 받은 순서대로 메시지를 기록하고, 앞선 메시지가 모두 끝난
+// 가장 마지막 메시지(워터마크)를 찾습니다.
+type offsetTracker struct {
+	mu      sync.Mutex
+	pending []*sarama.ConsumerMessage
+	done    map[int64]bool
+}
+
+func (t *offsetTracker) add(msg *sarama.ConsumerMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, msg)
+}
+
+// complete는 msg가 끝났음을 기록하고, 워터마크가 앞으로 나아가면 잠금을 잡은 채로 mark를 호출합니다.
+// 잠금 안에서 호출하므로 워터마크는 항상 증가하는 순서로 표시됩니다.
+func (t *offsetTracker) complete(msg *sarama.ConsumerMessage, mark func(*sarama.ConsumerMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done == nil {
+		t.done = make(map[int64]bool)
+	}
+	t.done[msg.Offset] = true
+
+	var watermark *sarama.ConsumerMessage
+	for len(t.pending) > 0 && t.done[t.pending[0].Offset] {
+		watermark = t.pending[0]
+		delete(t.done, watermark.Offset)
+		t.pending = t.pending[1:]
+	}
+	if watermark != nil {
+		mark(watermark)
+	}
+}
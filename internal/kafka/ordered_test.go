@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	pkgevents "github.com/hoo47/kafka_ex/pkg/events"
+)
+
+type fakeSession struct {
+	ctx    context.Context
+	claims map[string][]int32
+
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *fakeSession) Claims() map[string][]int32               { return s.claims }
+func (s *fakeSession) MemberID() string                         { return "member" }
+func (s *fakeSession) GenerationID() int32                      { return 1 }
+func (s *fakeSession) MarkOffset(string, int32, int64, string)  {}
+func (s *fakeSession) Commit()                                  {}
+func (s *fakeSession) ResetOffset(string, int32, int64, string) {}
+func (s *fakeSession) Context() context.Context                 { return s.ctx }
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type fakeClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return "app.events" }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// orderingHandler는 키별로 처리한 순서를 기록하고, 동시에 실행된 최대 핸들러 수를 잽니다.
+type orderingHandler struct {
+	running, peak atomic.Int32
+
+	mu    sync.Mutex
+	order map[string][]string
+}
+
+func (h *orderingHandler) EventType() string { return "AppInstallEvent" }
+
+func (h *orderingHandler) Handle(_ context.Context, msg proto.Message) error {
+	n := h.running.Add(1)
+	defer h.running.Add(-1)
+	for {
+		peak := h.peak.Load()
+		if n <= peak || h.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	event := msg.(*pkgevents.AppInstallEvent)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.order[event.AppId] = append(h.order[event.AppId], event.ChannelId)
+	return nil
+}
+
+func TestConsumer_ConcurrentKeepsKeyOrder(t *testing.T) {
+	h := &orderingHandler{order: make(map[string][]string)}
+	consumer, template := newTestConsumer(t, h, HandlingPolicy{MaxAttempts: 1})
+	consumer.defaultConcurrency = 4
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 40)}
+	for i := 0; i < 40; i++ {
+		appID := fmt.Sprintf("app-%d", i%5)
+		value, err := consumer.codec.Serialize("AppInstallEvent", &pkgevents.AppInstallEvent{
+			AppId:     appID,
+			ChannelId: fmt.Sprint(i),
+		})
+		require.NoError(t, err)
+		claim.messages <- &sarama.ConsumerMessage{
+			Topic:   "app.events",
+			Offset:  int64(i),
+			Key:     []byte(appID),
+			Value:   value,
+			Headers: template.Headers,
+		}
+	}
+	close(claim.messages)
+
+	session := &fakeSession{ctx: context.Background()}
+	require.NoError(t, consumer.ConsumeClaim(session, claim))
+
+	for i := 0; i < 5; i++ {
+		var want []string
+		for j := i; j < 40; j += 5 {
+			want = append(want, fmt.Sprint(j))
+		}
+		assert.Equal(t, want, h.order[fmt.Sprintf("app-%d", i)])
+	}
+	assert.Greater(t, h.peak.Load(), int32(1))
+	require.NotEmpty(t, session.marked)
+	assert.Equal(t, int64(39), session.marked[len(session.marked)-1])
+	assert.IsIncreasing(t, session.marked)
+}
+
+func TestOffsetTracker_MarksLowestContiguousOffset(t *testing.T) {
+	msgs := make([]*sarama.ConsumerMessage, 4)
+	tracker := &offsetTracker{}
+	for i := range msgs {
+		msgs[i] = &sarama.ConsumerMessage{Offset: int64(10 + i)}
+		tracker.add(msgs[i])
+	}
+
+	var marked []int64
+	mark := func(msg *sarama.ConsumerMessage) { marked = append(marked, msg.Offset) }
+
+	tracker.complete(msgs[2], mark)
+	tracker.complete(msgs[1], mark)
+	assert.Empty(t, marked)
+
+	tracker.complete(msgs[0], mark)
+	assert.Equal(t, []int64{12}, marked)
+
+	tracker.complete(msgs[3], mark)
+	assert.Equal(t, []int64{12, 13}, marked)
+}
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+)
+
+// HandlingPolicy는 재시작 없이 바꿀 수 있는 메시지 처리 설정입니다.
+type HandlingPolicy struct {
+	// Disabled에 있는 이벤트 타입의 메시지는 처리하지 않고 오프셋만 커밋합니다.
+	Disabled map[string]bool
+	// UnknownType은 핸들러가 없는 이벤트 타입의 메시지를 처리하는 방식이며, UnknownType 상수 중 하나입니다.
+	UnknownType string
+	// Timeout은 이벤트 하나를 처리하는 제한 시간이며, Timeouts에 있는 이벤트 타입은 그 값을 사용합니다.
+	// 0이면 제한하지 않습니다.
+	Timeout  time.Duration
+	Timeouts map[string]time.Duration
+	// MaxAttempts는 핸들러 호출 최대 횟수이며, 1 이하이면 재시도하지 않습니다.
+	MaxAttempts int
+	// InitialBackoff는 첫 실패 후 대기 시간이며, 이후 실패마다 두 배씩 늘어나 MaxBackoff에서 멈춥니다.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// BatchSize와 BatchMaxAge는 배치 핸들러에 전달할 배치의 최대 크기와 최대 대기 시간입니다.
+	BatchSize   int
+	BatchMaxAge time.Duration
+	// RateLimit은 초당 처리할 최대 메시지 수이며, 0이면 제한하지 않습니다.
+	RateLimit float64
+	Burst     int
+	// BreakerThreshold는 서킷 브레이커를 여는 연속 실패 횟수이며, 0이면 브레이커를 사용하지 않습니다.
+	// 브레이커는 BreakerOpenDuration 동안 열려 있으며, BreakerDependencies에 있는 이벤트 타입은
+	// 의존 대상 이름의 브레이커를 공유합니다.
+	BreakerThreshold    int
+	BreakerOpenDuration time.Duration
+	BreakerDependencies map[string]string
+}
+
+// 핸들러가 없는 이벤트 타입의 메시지를 처리하는 방식입니다.
+const (
+	// UnknownTypeSkip은 조용히 건너뜁니다.
+	UnknownTypeSkip = "skip"
+	// UnknownTypeMetric은 건너뛰고 이벤트 타입별 개수를 지표로 남깁니다. 비어 있으면 이 방식을 사용합니다.
+	UnknownTypeMetric = "metric"
+	// UnknownTypeDLQ는 재시도하지 않고 DLQ로 보냅니다.
+	UnknownTypeDLQ = "dlq"
+	// UnknownTypeHalt는 파티션을 멈추고 해당 메시지부터 커밋하지 않습니다.
+	UnknownTypeHalt = "halt"
+)
+
+// NewHandlingPolicy는 설정 파일의 handlers 항목을 HandlingPolicy로 변환합니다.
+func NewHandlingPolicy(cfg *config.Config) HandlingPolicy {
+	handlers := cfg.Handlers
+	disabled := make(map[string]bool, len(handlers.Disabled))
+	for _, eventType := range handlers.Disabled {
+		disabled[eventType] = true
+	}
+	return HandlingPolicy{
+		Disabled:       disabled,
+		UnknownType:    handlers.UnknownType,
+		Timeout:        handlers.Timeout,
+		Timeouts:       handlers.Timeouts,
+		MaxAttempts:    handlers.Retry.MaxAttempts,
+		InitialBackoff: handlers.Retry.InitialBackoff,
+		MaxBackoff:     handlers.Retry.MaxBackoff,
+		BatchSize:      handlers.Batch.MaxSize,
+		BatchMaxAge:    handlers.Batch.MaxAge,
+		RateLimit:      handlers.RateLimit.PerSecond,
+		Burst:          handlers.RateLimit.Burst,
+
+		BreakerThreshold:    handlers.CircuitBreaker.FailureThreshold,
+		BreakerOpenDuration: handlers.CircuitBreaker.OpenDuration,
+		BreakerDependencies: handlers.CircuitBreaker.Dependencies,
+	}
+}
+
+// timeout은 eventType의 처리 제한 시간을 반환합니다.
+func (p HandlingPolicy) timeout(eventType string) time.Duration {
+	if timeout, ok := p.Timeouts[eventType]; ok {
+		return timeout
+	}
+	return p.Timeout
+}
+
+// backoff는 attempt번째 실패 후의 대기 시간을 반환합니다.
+func (p HandlingPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,163 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/config"
+)
+
+// TopicDrift는 이미 있는 토픽의 설정이 선언과 다른 항목입니다.
+type TopicDrift struct {
+	Topic   string
+	Setting string
+	Want    string
+	Actual  string
+}
+
+func (d TopicDrift) String() string {
+	return fmt.Sprintf("%s: %s is %s, want %s", d.Topic, d.Setting, d.Actual, d.Want)
+}
+
+// ProvisionReport는 프로비저닝 결과입니다.
+type ProvisionReport struct {
+	Created []string
+	Drift   []TopicDrift
+}
+
+// TopicProvisioner는 선언된 토픽 중 없는 토픽을 생성하고, 이미 있는 토픽은 선언과 비교합니다.
+// 파티션 수를 늘리면 키별 순서가 깨질 수 있으므로 이미 있는 토픽은 변경하지 않고 보고만 합니다.
+type TopicProvisioner struct {
+	admin sarama.ClusterAdmin
+}
+
+func NewTopicProvisioner(admin sarama.ClusterAdmin) *TopicProvisioner {
+	return &TopicProvisioner{admin: admin}
+}
+
+// Provision은 specs에 선언된 토픽을 프로비저닝합니다. dryRun이 true이면 토픽을 생성하지 않고
+// 생성할 토픽을 Created에 담아 반환합니다.
+func (p *TopicProvisioner) Provision(specs []config.TopicSpec, dryRun bool) (*ProvisionReport, error) {
+	existing, err := p.admin.ListTopics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+
+	report := &ProvisionReport{}
+	for _, spec := range specs {
+		detail, ok := existing[spec.Name]
+		if !ok {
+			if err := p.create(spec, dryRun); err != nil {
+				return report, err
+			}
+			report.Created = append(report.Created, spec.Name)
+			continue
+		}
+
+		drift, err := p.compare(spec, detail)
+		if err != nil {
+			return report, err
+		}
+		report.Drift = append(report.Drift, drift...)
+	}
+	return report, nil
+}
+
+func (p *TopicProvisioner) create(spec config.TopicSpec, dryRun bool) error {
+	if spec.Partitions <= 0 || spec.ReplicationFactor <= 0 {
+		return fmt.Errorf("topic %s: partitions and replication_factor are required to create it", spec.Name)
+	}
+
+	entries := make(map[string]*string)
+	for name, value := range topicConfig(spec) {
+		value := value
+		entries[name] = &value
+	}
+
+	err := p.admin.CreateTopic(spec.Name, &sarama.TopicDetail{
+		NumPartitions:     spec.Partitions,
+		ReplicationFactor: spec.ReplicationFactor,
+		ConfigEntries:     entries,
+	}, dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to create topic %s: %w", spec.Name, err)
+	}
+	return nil
+}
+
+func (p *TopicProvisioner) compare(spec config.TopicSpec, detail sarama.TopicDetail) ([]TopicDrift, error) {
+	var drift []TopicDrift
+	if spec.Partitions > 0 && detail.NumPartitions != spec.Partitions {
+		drift = append(drift, TopicDrift{
+			Topic:   spec.Name,
+			Setting: "partitions",
+			Want:    strconv.Itoa(int(spec.Partitions)),
+			Actual:  strconv.Itoa(int(detail.NumPartitions)),
+		})
+	}
+	if spec.ReplicationFactor > 0 && detail.ReplicationFactor != spec.ReplicationFactor {
+		drift = append(drift, TopicDrift{
+			Topic:   spec.Name,
+			Setting: "replication_factor",
+			Want:    strconv.Itoa(int(spec.ReplicationFactor)),
+			Actual:  strconv.Itoa(int(detail.ReplicationFactor)),
+		})
+	}
+
+	want := topicConfig(spec)
+	if len(want) == 0 {
+		return drift, nil
+	}
+
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// ListTopics는 기본값인 설정을 돌려주지 않으므로 선언된 설정은 기본값까지 함께 조회합니다.
+	entries, err := p.admin.DescribeConfig(sarama.ConfigResource{
+		Type:        sarama.TopicResource,
+		Name:        spec.Name,
+		ConfigNames: names,
+	})
+	if err != nil {
+		return drift, fmt.Errorf("failed to describe topic %s: %w", spec.Name, err)
+	}
+
+	actual := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		actual[entry.Name] = entry.Value
+	}
+	for _, name := range names {
+		if actual[name] != want[name] {
+			drift = append(drift, TopicDrift{
+				Topic:   spec.Name,
+				Setting: name,
+				Want:    want[name],
+				Actual:  actual[name],
+			})
+		}
+	}
+	return drift, nil
+}
+
+// topicConfig는 선언을 Kafka 토픽 설정으로 변환합니다.
+func topicConfig(spec config.TopicSpec) map[string]string {
+	entries := make(map[string]string, len(spec.Config)+2)
+	for name, value := range spec.Config {
+		entries[name] = value
+	}
+	if spec.CleanupPolicy != "" {
+		entries["cleanup.policy"] = spec.CleanupPolicy
+	}
+	switch {
+	case spec.Retention < 0:
+		entries["retention.ms"] = "-1"
+	case spec.Retention > 0:
+		entries["retention.ms"] = strconv.FormatInt(spec.Retention.Milliseconds(), 10)
+	}
+	return entries
+}
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+)
+
+func newMockAdmin(t *testing.T) (*sarama.MockBroker, sarama.ClusterAdmin) {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	// app.events는 파티션 3개로 이미 있고, cleanup.policy가 선언과 다릅니다.
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetController(broker.BrokerID()).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("app.events", 0, broker.BrokerID()).
+			SetLeader("app.events", 1, broker.BrokerID()).
+			SetLeader("app.events", 2, broker.BrokerID()),
+		"DescribeConfigsRequest": sarama.NewMockWrapper(&sarama.DescribeConfigsResponse{
+			Version: 2,
+			Resources: []*sarama.ResourceResponse{{
+				Type: sarama.TopicResource,
+				Name: "app.events",
+				Configs: []*sarama.ConfigEntry{
+					{Name: "cleanup.policy", Value: "compact", Source: sarama.SourceTopic},
+					{Name: "retention.ms", Value: "604800000", Source: sarama.SourceDefault},
+				},
+			}},
+		}),
+		"CreateTopicsRequest": sarama.NewMockCreateTopicsResponse(t),
+	})
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_1_0_0
+	admin, err := sarama.NewClusterAdmin([]string{broker.Addr()}, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+	return broker, admin
+}
+
+func TestTopicProvisioner_Provision(t *testing.T) {
+	broker, admin := newMockAdmin(t)
+
+	report, err := NewTopicProvisioner(admin).Provision([]config.TopicSpec{
+		{Name: "app.events", Partitions: 6, ReplicationFactor: 1, CleanupPolicy: "delete", Retention: 168 * time.Hour},
+		{Name: "app.events.dlq", Partitions: 1, ReplicationFactor: 1, Retention: -1},
+	}, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"app.events.dlq"}, report.Created)
+	assert.Equal(t, []TopicDrift{
+		{Topic: "app.events", Setting: "partitions", Want: "6", Actual: "3"},
+		{Topic: "app.events", Setting: "cleanup.policy", Want: "delete", Actual: "compact"},
+	}, report.Drift)
+
+	var created *sarama.CreateTopicsRequest
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*sarama.CreateTopicsRequest); ok {
+			created = req
+		}
+	}
+	require.NotNil(t, created)
+	detail := created.TopicDetails["app.events.dlq"]
+	require.NotNil(t, detail)
+	assert.Equal(t, int32(1), detail.NumPartitions)
+	assert.Equal(t, "-1", *detail.ConfigEntries["retention.ms"])
+	assert.False(t, created.ValidateOnly)
+}
+
+func TestTopicProvisioner_RequiresSizeToCreate(t *testing.T) {
+	_, admin := newMockAdmin(t)
+
+	_, err := NewTopicProvisioner(admin).Provision([]config.TopicSpec{{Name: "app.events.retry"}}, true)
+	assert.ErrorContains(t, err, "partitions and replication_factor are required")
+}
@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+)
+
+// applySecurity는 kafka.tls와 kafka.sasl 설정을 sarama.Config에 적용합니다.
+func applySecurity(saramaConfig *sarama.Config, cfg *config.Config) []error {
+	var errs []error
+
+	tlsConfig, err := cfg.Kafka.TLS.Load()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("kafka.tls: %w", err))
+	} else if tlsConfig != nil {
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+
+	sasl := cfg.Kafka.SASL
+	if sasl.Mechanism == "" {
+		return errs
+	}
+
+	switch sasl.Mechanism {
+	case sarama.SASLTypePlaintext:
+	case sarama.SASLTypeSCRAMSHA256:
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hashGen: scram.SHA256}
+		}
+	case sarama.SASLTypeSCRAMSHA512:
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hashGen: scram.SHA512}
+		}
+	default:
+		return append(errs, fmt.Errorf("kafka.sasl.mechanism: unsupported value %q", sasl.Mechanism))
+	}
+
+	password, err := sasl.Password.Resolve()
+	if err != nil {
+		return append(errs, fmt.Errorf("kafka.sasl.password: %w", err))
+	}
+
+	saramaConfig.Net.SASL.Enable = true
+	saramaConfig.Net.SASL.Handshake = true
+	saramaConfig.Net.SASL.Mechanism = sarama.SASLMechanism(sasl.Mechanism)
+	saramaConfig.Net.SASL.User = sasl.Username
+	saramaConfig.Net.SASL.Password = password
+	return errs
+}
+
+// scramClient는 sarama.SCRAMClient를 xdg-go/scram으로 구현합니다.
+type scramClient struct {
+	hashGen      scram.HashGeneratorFcn
+	conversation *scram.ClientConversation
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hashGen.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
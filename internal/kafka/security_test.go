@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+)
+
+func TestNewClientConfig_SASLFromEnv(t *testing.T) {
+	t.Setenv("KAFKA_SASL_PASSWORD", "s3cret")
+
+	cfg := &config.Config{}
+	cfg.Kafka.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+	cfg.Kafka.SASL.Username = "relay"
+	cfg.Kafka.SASL.Password.Env = "KAFKA_SASL_PASSWORD"
+
+	saramaConfig, err := NewClientConfig(cfg)
+	require.NoError(t, err)
+
+	assert.True(t, saramaConfig.Net.SASL.Enable)
+	assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512), saramaConfig.Net.SASL.Mechanism)
+	assert.Equal(t, "relay", saramaConfig.Net.SASL.User)
+	assert.Equal(t, "s3cret", saramaConfig.Net.SASL.Password)
+	assert.NotNil(t, saramaConfig.Net.SASL.SCRAMClientGeneratorFunc())
+}
+
+func TestNewClientConfig_SASLFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+
+	cfg := &config.Config{}
+	cfg.Kafka.SASL.Mechanism = sarama.SASLTypePlaintext
+	cfg.Kafka.SASL.Username = "relay"
+	cfg.Kafka.SASL.Password.File = path
+
+	saramaConfig, err := NewClientConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", saramaConfig.Net.SASL.Password)
+}
+
+func TestNewClientConfig_InvalidSecurity(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kafka.TLS.Enabled = true
+	cfg.Kafka.TLS.CAFile = filepath.Join(t.TempDir(), "missing.pem")
+	cfg.Kafka.SASL.Mechanism = "GSSAPI"
+
+	_, err := NewClientConfig(cfg)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "kafka.tls")
+	assert.ErrorContains(t, err, "kafka.sasl.mechanism")
+}
+
+func TestNewClientConfig_MissingSecretEnv(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Kafka.SASL.Mechanism = sarama.SASLTypePlaintext
+	cfg.Kafka.SASL.Username = "relay"
+	cfg.Kafka.SASL.Password.Env = "KAFKA_SASL_PASSWORD_UNSET"
+
+	_, err := NewClientConfig(cfg)
+	assert.ErrorContains(t, err, "KAFKA_SASL_PASSWORD_UNSET is not set")
+}
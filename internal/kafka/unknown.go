@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/hoo47/kafka_ex/internal/events"
+)
+
+// unknownEventTypes는 핸들러가 없어 건너뛴 메시지 수를 이벤트 타입별로 /debug/vars에 노출합니다.
+var unknownEventTypes = expvar.NewMap("kafka_unknown_event_types")
+
+// errHaltPartition은 파티션을 멈추고 메시지를 끝난 것으로 기록하지 않아야 함을 나타냅니다.
+var errHaltPartition = errors.New("partition halted")
+
+// unknownEventType은 핸들러와 기본 핸들러가 모두 없는 이벤트 타입의 메시지를 처리 방식에 따라 처리합니다.
+func (c *Consumer) unknownEventType(msg *sarama.ConsumerMessage, eventType, policy string) error {
+	if policy == UnknownTypeSkip {
+		return nil
+	}
+	unknownEventTypes.Add(eventType, 1)
+
+	err := fmt.Errorf("%w for event type: %s", events.ErrNoHandler, eventType)
+	switch policy {
+	case UnknownTypeDLQ:
+		return events.Permanent(err)
+	case UnknownTypeHalt:
+		return fmt.Errorf("%w: %w", errHaltPartition, err)
+	default:
+		c.logger.Debug("skipping unknown event type",
+			"type", eventType,
+			"topic", msg.Topic,
+			"partition", msg.Partition,
+			"offset", msg.Offset)
+		return nil
+	}
+}
+
+// halt는 msg의 파티션을 멈추고 세션이 끝날 때까지 기다립니다. msg를 끝난 것으로 기록하지 않으므로
+// 이후 메시지의 오프셋도 커밋되지 않으며, 다음 세션에서 msg부터 다시 처리합니다.
+func (c *Consumer) halt(ctx context.Context, msg *sarama.ConsumerMessage, err error) {
+	c.logger.Error("halting partition",
+		"error", err,
+		"topic", msg.Topic,
+		"partition", msg.Partition,
+		"offset", msg.Offset)
+	if c.pauser != nil {
+		c.pauser.Pause(map[string][]int32{msg.Topic: {msg.Partition}})
+	}
+	<-ctx.Done()
+}
+
+func rawMessage(msg *sarama.ConsumerMessage, eventType string) events.RawMessage {
+	headers := make(map[string][]byte, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[string(header.Key)] = header.Value
+	}
+	return events.RawMessage{
+		Type:      eventType,
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+		Timestamp: msg.Timestamp,
+	}
+}
@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+)
+
+// CatalogEntry describes one event type in the catalog.
+type CatalogEntry struct {
+	Type      string
+	Prototype proto.Message
+	Topic     string
+	Subject   string
+	// KeyField is nil when the aggregate ID is used as the message key.
+	KeyField protoreflect.FieldDescriptor
+	// Filter holds the field expressions that consumed events must match; see
+	// events.ParsePredicate for the syntax.
+	Filter []string
+}
+
+// Catalog maps event type names to their proto messages, topics and subjects.
+type Catalog struct {
+	entries map[string]CatalogEntry
+	types   []string
+}
+
+// NewCatalog resolves the events section of the config against the global proto
+// registry. The generated Go package of every message must be linked into the
+// binary, otherwise its full name cannot be resolved.
+func NewCatalog(specs []config.EventSpec) (*Catalog, error) {
+	c := &Catalog{entries: make(map[string]CatalogEntry, len(specs))}
+	for _, spec := range specs {
+		if _, ok := c.entries[spec.Type]; ok {
+			return nil, fmt.Errorf("duplicate event type: %s", spec.Type)
+		}
+
+		messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(spec.Proto))
+		if err != nil {
+			return nil, fmt.Errorf("event %s: failed to resolve proto message %s: %w", spec.Type, spec.Proto, err)
+		}
+
+		entry := CatalogEntry{
+			Type:      spec.Type,
+			Prototype: messageType.New().Interface(),
+			Topic:     spec.Topic,
+			Subject:   spec.Subject,
+			Filter:    spec.Filter,
+		}
+		if spec.KeyField != "" {
+			field := messageType.Descriptor().Fields().ByName(protoreflect.Name(spec.KeyField))
+			if field == nil {
+				return nil, fmt.Errorf("event %s: field %s not found in %s", spec.Type, spec.KeyField, spec.Proto)
+			}
+			if field.IsList() || field.IsMap() || field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind {
+				return nil, fmt.Errorf("event %s: key field %s must be a scalar field", spec.Type, spec.KeyField)
+			}
+			entry.KeyField = field
+		}
+
+		c.entries[spec.Type] = entry
+		c.types = append(c.types, spec.Type)
+	}
+	return c, nil
+}
+
+// Types returns the event types in declaration order.
+func (c *Catalog) Types() []string {
+	return append([]string(nil), c.types...)
+}
+
+// Entry returns the catalog entry for an event type.
+func (c *Catalog) Entry(eventType string) (CatalogEntry, bool) {
+	entry, ok := c.entries[eventType]
+	return entry, ok
+}
+
+// Topics returns the distinct topics of all events, sorted.
+func (c *Catalog) Topics() []string {
+	seen := make(map[string]bool)
+	var topics []string
+	for _, entry := range c.entries {
+		if !seen[entry.Topic] {
+			seen[entry.Topic] = true
+			topics = append(topics, entry.Topic)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Subjects returns the Schema Registry subject of each event type, in the form
+// expected by SchemaRegistry.RegisterSchemas.
+func (c *Catalog) Subjects() map[string]string {
+	subjects := make(map[string]string, len(c.entries))
+	for eventType, entry := range c.entries {
+		subjects[eventType] = entry.Subject
+	}
+	return subjects
+}
+
+// Key returns the value of the configured key field of msg. It returns false
+// when the event type has no key field or the field is empty.
+func (c *Catalog) Key(eventType string, msg proto.Message) (string, bool) {
+	entry, ok := c.entries[eventType]
+	if !ok || entry.KeyField == nil || msg == nil {
+		return "", false
+	}
+
+	m := msg.ProtoReflect()
+	if m.Descriptor().FullName() != entry.KeyField.ContainingMessage().FullName() {
+		return "", false
+	}
+	key := m.Get(entry.KeyField).String()
+	if key == "" {
+		return "", false
+	}
+	return key, true
+}
@@ -0,0 +1,45 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+	pkgevents "github.com/hoo47/kafka_ex/pkg/events"
+)
+
+func TestCatalog_ResolvesProtoMessages(t *testing.T) {
+	catalog, err := NewCatalog([]config.EventSpec{
+		{Type: "AppInstallEvent", Proto: "events.AppInstallEvent", Topic: "app.events", Subject: "app.events-AppInstallEvent", KeyField: "channel_id"},
+		{Type: "AppUninstallEvent", Proto: "events.AppUninstallEvent", Topic: "app.lifecycle", Subject: "app.lifecycle-AppUninstallEvent"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"AppInstallEvent", "AppUninstallEvent"}, catalog.Types())
+	assert.Equal(t, []string{"app.events", "app.lifecycle"}, catalog.Topics())
+	assert.Equal(t, map[string]string{
+		"AppInstallEvent":   "app.events-AppInstallEvent",
+		"AppUninstallEvent": "app.lifecycle-AppUninstallEvent",
+	}, catalog.Subjects())
+
+	entry, ok := catalog.Entry("AppInstallEvent")
+	require.True(t, ok)
+	assert.IsType(t, &pkgevents.AppInstallEvent{}, entry.Prototype)
+
+	key, ok := catalog.Key("AppInstallEvent", &pkgevents.AppInstallEvent{AppId: "app-1", ChannelId: "channel-1"})
+	assert.True(t, ok)
+	assert.Equal(t, "channel-1", key)
+
+	_, ok = catalog.Key("AppUninstallEvent", &pkgevents.AppUninstallEvent{AppId: "app-1"})
+	assert.False(t, ok)
+}
+
+func TestCatalog_Errors(t *testing.T) {
+	_, err := NewCatalog([]config.EventSpec{{Type: "Missing", Proto: "events.MissingEvent"}})
+	assert.ErrorContains(t, err, "failed to resolve proto message events.MissingEvent")
+
+	_, err = NewCatalog([]config.EventSpec{{Type: "AppInstallEvent", Proto: "events.AppInstallEvent", KeyField: "app"}})
+	assert.ErrorContains(t, err, "field app not found")
+}
@@ -3,6 +3,7 @@ package schema
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"google.golang.org/protobuf/proto"
@@ -12,6 +13,11 @@ const (
 	magicByte = 0x0
 )
 
+// ErrMalformedMessage is returned by Deserialize when the payload itself is invalid
+// (bad framing, wrong schema ID or undecodable bytes), as opposed to a registry lookup failure.
+// Retrying such a message never succeeds.
+var ErrMalformedMessage = errors.New("malformed message")
+
 type Codec struct {
 	registry Registry
 }
@@ -65,12 +71,12 @@ func (c *Codec) Serialize(eventType string, msg proto.Message) ([]byte, error) {
 // Deserialize converts Schema Registry format back to Proto message
 func (c *Codec) Deserialize(data []byte, eventType string) (proto.Message, error) {
 	if len(data) < 5 {
-		return nil, fmt.Errorf("message too short")
+		return nil, fmt.Errorf("%w: message too short", ErrMalformedMessage)
 	}
 
 	// Magic Byte 확인
 	if data[0] != magicByte {
-		return nil, fmt.Errorf("invalid magic byte")
+		return nil, fmt.Errorf("%w: invalid magic byte", ErrMalformedMessage)
 	}
 
 	// Schema ID 읽기
@@ -84,13 +90,13 @@ func (c *Codec) Deserialize(data []byte, eventType string) (proto.Message, error
 
 	// Schema ID 검증
 	if int(schemaID) != expectedID {
-		return nil, fmt.Errorf("schema ID mismatch: expected %d, got %d", expectedID, schemaID)
+		return nil, fmt.Errorf("%w: schema ID mismatch: expected %d, got %d", ErrMalformedMessage, expectedID, schemaID)
 	}
 
 	// 메시지 복제 및 역직렬화
 	msg := proto.Clone(prototype)
 	if err := proto.Unmarshal(data[5:], msg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+		return nil, fmt.Errorf("%w: failed to unmarshal message: %w", ErrMalformedMessage, err)
 	}
 
 	return msg, nil
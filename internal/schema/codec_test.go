@@ -112,7 +112,7 @@ func TestCodec_DeserializeErrors(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			_, err := codec.Deserialize(tt.input, tt.eventType)
-			assert.Error(t, err)
+			assert.ErrorIs(t, err, ErrMalformedMessage)
 			assert.Contains(t, err.Error(), tt.wantErr)
 		})
 	}
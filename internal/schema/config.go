@@ -0,0 +1,40 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/hoo47/kafka_ex/internal/config"
+)
+
+// NewSchemaRegistryFromConfig creates a registry client from the schema_registry
+// section of the config, loading TLS files and resolving secrets.
+func NewSchemaRegistryFromConfig(cfg *config.Config) (*SchemaRegistry, error) {
+	registryConfig := cfg.SchemaRegistry
+
+	var opts []RegistryOption
+	tlsConfig, err := registryConfig.TLS.Load()
+	if err != nil {
+		return nil, fmt.Errorf("schema_registry.tls: %w", err)
+	}
+	if tlsConfig != nil {
+		opts = append(opts, WithTLSConfig(tlsConfig))
+	}
+
+	auth := registryConfig.Auth
+	switch {
+	case auth.Username != "":
+		password, err := auth.Password.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("schema_registry.auth.password: %w", err)
+		}
+		opts = append(opts, WithBasicAuth(auth.Username, password))
+	case auth.BearerToken.IsSet():
+		token, err := auth.BearerToken.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("schema_registry.auth.bearer_token: %w", err)
+		}
+		opts = append(opts, WithBearerToken(token))
+	}
+
+	return NewSchemaRegistry(registryConfig.URL, opts...), nil
+}
@@ -1,7 +1,10 @@
 package schema
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/riferrei/srclient"
 	"google.golang.org/protobuf/proto"
@@ -21,9 +24,66 @@ type SchemaRegistry struct {
 	}
 }
 
-func NewSchemaRegistry(url string) *SchemaRegistry {
+// RegistryOption configures the Schema Registry client.
+type RegistryOption func(*registryOptions)
+
+type registryOptions struct {
+	tlsConfig   *tls.Config
+	username    string
+	password    string
+	bearerToken string
+}
+
+// WithTLSConfig makes the client connect to the registry with the given TLS configuration.
+func WithTLSConfig(tlsConfig *tls.Config) RegistryOption {
+	return func(o *registryOptions) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// WithBasicAuth authenticates requests with HTTP basic auth.
+func WithBasicAuth(username, password string) RegistryOption {
+	return func(o *registryOptions) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// WithBearerToken authenticates requests with a bearer token.
+// It is ignored when basic auth credentials are also given.
+func WithBearerToken(token string) RegistryOption {
+	return func(o *registryOptions) {
+		o.bearerToken = token
+	}
+}
+
+func NewSchemaRegistry(url string, opts ...RegistryOption) *SchemaRegistry {
+	var o registryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var clientOpts []srclient.Option
+	if o.tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = o.tlsConfig
+		// Keep srclient's default timeout when replacing its HTTP client.
+		clientOpts = append(clientOpts, srclient.WithClient(&http.Client{
+			Transport: transport,
+			Timeout:   5 * time.Second,
+		}))
+	}
+
+	client := srclient.NewSchemaRegistryClient(url, clientOpts...)
+	switch {
+	case o.username != "":
+		client.SetCredentials(o.username, o.password)
+	case o.bearerToken != "":
+		client.SetBearerToken(o.bearerToken)
+	}
+
 	return &SchemaRegistry{
-		client: srclient.CreateSchemaRegistryClient(url),
+		client: client,
 		schemas: make(map[string]struct {
 			id        int
 			prototype proto.Message